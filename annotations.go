@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"zgo.at/uni/v2/unidata"
+)
+
+// User-defined aliases for codepoints and keywords for emojis, from the
+// annotations file.
+var (
+	userAliases  map[rune][]string
+	userKeywords map[string][]string // Emoji without modifiers → keywords.
+)
+
+// annotationsPath gets the path to the annotations file, which is next to the
+// config file.
+func annotationsPath() (string, error) {
+	p, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), "annotations"), nil
+}
+
+// readAnnotations reads the annotations file, which has lines in the form of:
+//
+//	U+2713 = tick, done
+//	🚢     = ship it, deploy
+//
+// The codepoint can be in any format that print accepts, or the character
+// itself. Modifiers such as skin tones are ignored for emojis, so keywords for
+// 👍🏽 also apply to 👍 and 👍🏿.
+//
+// It's not an error if the file doesn't exist.
+func readAnnotations() error {
+	userAliases, userKeywords = make(map[rune][]string), make(map[string][]string)
+
+	path, err := annotationsPath()
+	if err != nil {
+		return err
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer fp.Close()
+
+	var (
+		scan   = bufio.NewScanner(fp)
+		lineno int
+	)
+	for scan.Scan() {
+		lineno++
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		// Start looking for the = after the first character, so that "=" can
+		// be annotated.
+		_, n := utf8.DecodeRuneInString(line)
+		i := strings.IndexByte(line[n:], '=')
+		if i == -1 {
+			return fmt.Errorf("%s:%d: not in the form of codepoint = keywords: %q", path, lineno, line)
+		}
+		var (
+			text     = strings.TrimSpace(line[:n+i])
+			keywords []string
+		)
+		for _, k := range strings.Split(line[n+i+1:], ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keywords = append(keywords, k)
+			}
+		}
+		if len(keywords) == 0 {
+			return fmt.Errorf("%s:%d: no keywords for %q", path, lineno, text)
+		}
+
+		if utf8.RuneCountInString(text) > 1 {
+			if cp, err := unidata.FromString(text); err == nil {
+				text = string(cp.Codepoint)
+			}
+		}
+		if utf8.RuneCountInString(text) == 1 {
+			cp, _ := utf8.DecodeRuneInString(text)
+			userAliases[cp] = append(userAliases[cp], keywords...)
+		}
+		base, _, ok := unidata.ParseEmoji(text)
+		if !ok {
+			if utf8.RuneCountInString(text) > 1 {
+				return fmt.Errorf("%s:%d: not a codepoint or emoji: %q", path, lineno, text)
+			}
+			continue
+		}
+		for _, k := range keywords {
+			userKeywords[base.String()] = append(userKeywords[base.String()], strings.ToLower(k))
+		}
+	}
+	return scan.Err()
+}
+
+// aliases gets the aliases for a codepoint, including the ones from the
+// annotations file.
+func aliases(info unidata.Codepoint) []string {
+	if a := userAliases[info.Codepoint]; len(a) > 0 {
+		return append(slices.Clip(info.Aliases()), a...)
+	}
+	return info.Aliases()
+}
+
+// cldr gets the CLDR keywords for an emoji, including the ones from the
+// annotations file.
+func cldr(e unidata.Emoji) []string {
+	if len(userKeywords) == 0 {
+		return e.CLDR
+	}
+	kw, ok := userKeywords[e.String()]
+	if !ok {
+		if base, _, ok := unidata.ParseEmoji(e.String()); ok {
+			kw = userKeywords[base.String()]
+		}
+	}
+	if len(kw) > 0 {
+		return append(slices.Clip(e.CLDR), kw...)
+	}
+	return e.CLDR
+}
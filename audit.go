@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// Rules for audit, in the order they're checked.
+var auditRules = []struct{ name, desc string }{
+	{"bidi", "Bidirectional control character, which can make code display differently from how it's parsed (CVE-2021-42574)."},
+	{"invisible", "Zero-width or other default-ignorable character."},
+	{"tag", "Tag character outside of an emoji flag sequence."},
+	{"control", "Control character other than tab, newline, carriage return, or form feed."},
+	{"nfc", "Text that is not normalized to NFC."},
+	{"mixed-script", "Identifier that mixes scripts, such as Latin and Cyrillic."},
+	{"confusable", "Character that looks like ASCII in a word with ASCII letters."},
+}
+
+type (
+	// auditProblem is a problem found by audit.
+	auditProblem struct {
+		Path    string `json:"path"`
+		Line    int    `json:"line"`
+		Col     int    `json:"col"`
+		Offset  int    `json:"offset"`
+		Rule    string `json:"rule"`
+		Cpoint  string `json:"cpoint"`
+		Message string `json:"message"`
+		Fixed   bool   `json:"fixed"`
+
+		cp      rune
+		size    int // Length in bytes, for -fix.
+		runeCol int // Column in codepoints, for SARIF.
+	}
+
+	// auditAllow is an entry in the allowlist: the codepoints and rules that
+	// are allowed in files matching glob, or all files if glob is "".
+	auditAllow struct {
+		glob   string
+		rules  []string
+		ranges [][2]rune
+	}
+
+	auditConfig struct {
+		rules map[string]bool
+		allow []auditAllow
+		fix   bool
+	}
+)
+
+// parseAuditRules parses the -rules flag; this is a comma-separated list of
+// rules to check. Rules prefixed with "-" are removed from the list, and if
+// there are only removals they're removed from the default of all rules.
+func parseAuditRules(s string) (map[string]bool, error) {
+	var (
+		rules = make(map[string]bool)
+		list  = strings.Split(s, ",")
+	)
+	if s == "" || !slices.ContainsFunc(list, func(r string) bool { return !strings.HasPrefix(r, "-") }) {
+		for _, r := range auditRules {
+			rules[r.name] = true
+		}
+	}
+	for _, r := range list {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		name := strings.TrimPrefix(r, "-")
+		if !slices.ContainsFunc(auditRules, func(a struct{ name, desc string }) bool { return a.name == name }) {
+			return nil, fmt.Errorf("invalid value for -rules: %q", r)
+		}
+		rules[name] = !strings.HasPrefix(r, "-")
+	}
+	return rules, nil
+}
+
+// readAllowlist reads the allowlist file. Every line is a list of codepoints,
+// ranges of codepoints, or rule names that are allowed. Codepoints need a U+
+// prefix, so that a glob such as "cafe" isn't read as a codepoint. If the first
+// field isn't any of those it's a glob, and the line only applies to files
+// matching it. For example:
+//
+//	# Comment
+//	U+00A0 U+2000..U+200A
+//	*.md   U+2019 confusable
+//	testdata/*.txt bidi invisible
+func readAllowlist(path string) ([]auditAllow, error) {
+	if path == "" {
+		return nil, nil
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var (
+		allow  []auditAllow
+		scan   = bufio.NewScanner(fp)
+		lineno int
+		isCP   = func(s string) bool { return len(s) > 2 && strings.EqualFold(s[:2], "U+") }
+	)
+	for scan.Scan() {
+		lineno++
+		line, _, _ := strings.Cut(scan.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var a auditAllow
+		for i, f := range fields {
+			if slices.ContainsFunc(auditRules, func(r struct{ name, desc string }) bool { return r.name == f }) {
+				a.rules = append(a.rules, f)
+				continue
+			}
+
+			s := strings.SplitN(f, "..", 2)
+			if len(s) == 1 {
+				s = append(s, s[0])
+			}
+			if isCP(s[0]) && isCP(s[1]) {
+				start, err := unidata.FromString(s[0])
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", path, lineno, err)
+				}
+				end, err := unidata.FromString(s[1])
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", path, lineno, err)
+				}
+				a.ranges = append(a.ranges, [2]rune{start.Codepoint, end.Codepoint})
+				continue
+			}
+
+			if i > 0 {
+				return nil, fmt.Errorf("%s:%d: not a codepoint (U+2019), range (U+2000..U+200A), or rule: %q", path, lineno, f)
+			}
+			if _, err := filepath.Match(f, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineno, err)
+			}
+			a.glob = f
+		}
+		allow = append(allow, a)
+	}
+	return allow, scan.Err()
+}
+
+func (c auditConfig) allowed(path, rule string, cp rune) bool {
+	for _, a := range c.allow {
+		if a.glob != "" && !matchPath(a.glob, path) {
+			continue
+		}
+		if slices.Contains(a.rules, rule) {
+			return true
+		}
+		for _, r := range a.ranges {
+			if cp >= r[0] && cp <= r[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPath reports if the glob matches the path, or the end of the path: "*.go"
+// and "sub/*.go" both match "/src/sub/a.go".
+func matchPath(glob, path string) bool {
+	path = filepath.ToSlash(path)
+	for {
+		if m, _ := filepath.Match(glob, path); m {
+			return true
+		}
+		i := strings.IndexByte(path, '/')
+		if i == -1 {
+			return false
+		}
+		path = path[i+1:]
+	}
+}
+
+// fixable reports if this problem can be safely fixed by removing the
+// codepoint.
+func (p auditProblem) fixable() bool {
+	switch p.Rule {
+	case "bidi": // Embeddings, overrides, and isolates; keep the marks.
+		return (p.cp >= 0x202a && p.cp <= 0x202e) || (p.cp >= 0x2066 && p.cp <= 0x2069)
+	case "invisible":
+		return p.cp == 0x200b || p.cp == 0x2060 || p.cp == 0xfeff
+	case "tag":
+		return true
+	}
+	return false
+}
+
+func inProperty(p unidata.Property, r rune) bool {
+	for _, rng := range unidata.Properties[p].Ranges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Sets of scripts that are commonly mixed, from the "Highly Restrictive" level
+// in UTS #39.
+var allowedScripts = [][]unidata.Script{
+	{unidata.ScriptLatin, unidata.ScriptHan, unidata.ScriptHiragana, unidata.ScriptKatakana},
+	{unidata.ScriptLatin, unidata.ScriptHan, unidata.ScriptBopomofo},
+	{unidata.ScriptLatin, unidata.ScriptHan, unidata.ScriptHangul},
+}
+
+// auditText finds all problems in the text.
+func auditText(path string, text []byte, conf auditConfig) []auditProblem {
+	var (
+		problems []auditProblem
+		scripts  = make(map[rune]unidata.Script)
+		offset   int
+	)
+	for lineno, line := range bytes.SplitAfter(text, []byte("\n")) {
+		var (
+			runes   = make([]rune, 0, len(line))
+			offsets = make([]int, 0, len(line))
+			ascii   = true
+		)
+		for i := 0; i < len(line); {
+			r, s := utf8.DecodeRune(line[i:])
+			runes, offsets = append(runes, r), append(offsets, i)
+			ascii = ascii && r < 0x80
+			i += s
+		}
+
+		add := func(i int, rule, msg string) {
+			if !conf.rules[rule] || conf.allowed(path, rule, runes[i]) {
+				return
+			}
+			info, _ := unidata.Find(runes[i])
+			if msg == "" {
+				msg = info.FormatCodepoint() + " " + info.Name()
+			}
+			problems = append(problems, auditProblem{
+				Path: path, Line: lineno + 1, Col: offsets[i] + 1, Offset: offset + offsets[i],
+				Rule: rule, Cpoint: info.FormatCodepoint(), Message: msg,
+				cp: runes[i], size: utf8.RuneLen(runes[i]), runeCol: i + 1,
+			})
+		}
+
+		var seq []map[string]string
+		if !ascii {
+			seq = sequenceColumns(runes)
+		}
+		for i, r := range runes {
+			if r < 0x80 {
+				if (r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f') || r == 0x7f {
+					add(i, "control", "")
+				}
+				continue
+			}
+
+			inEmoji := seq[i] != nil
+			info, _ := unidata.Find(r)
+			switch {
+			case r == utf8.RuneError:
+				// Invalid UTF-8; hexdump is more useful for this.
+			case inProperty(unidata.PropBidiControl, r):
+				add(i, "bidi", "")
+			case r >= 0xe0000 && r <= 0xe007f:
+				if !inEmoji {
+					add(i, "tag", "")
+				}
+			case info.Category() == unidata.CatControl:
+				add(i, "control", "")
+			case info.Category() == unidata.CatFormat, inProperty(unidata.PropOtherDefaultIgnorableCodePoint, r),
+				inProperty(unidata.PropVariationSelector, r):
+				if !inEmoji && !(r == 0xfeff && offset+offsets[i] == 0) { // BOM at the start is fine.
+					add(i, "invisible", "")
+				}
+			}
+		}
+		if ascii {
+			offset += len(line)
+			continue
+		}
+
+		// NFC
+		for i := 0; i < len(line); {
+			n := norm.NFC.QuickSpan(line[i:])
+			if i+n == len(line) {
+				break
+			}
+			i += n
+			seg := line[i : i+norm.NFC.NextBoundary(line[i:], true)]
+			if nfc := norm.NFC.Bytes(seg); !bytes.Equal(seg, nfc) {
+				// The segment should always start at a codepoint, but use the
+				// codepoint it's in rather than risk an index of -1.
+				j, ok := slices.BinarySearch(offsets, i)
+				if !ok {
+					j--
+				}
+				add(j, "nfc", fmt.Sprintf("%s is %s in NFC", cpoints([]rune(string(seg))), cpoints([]rune(string(nfc)))))
+			}
+			i += len(seg)
+		}
+
+		// Words with mixed scripts or confusables.
+		for start := 0; start < len(runes); start++ {
+			end := start
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsMark(runes[end]) ||
+				unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			if end == start {
+				continue
+			}
+			word := runes[start:end]
+
+			var (
+				found    []unidata.Script
+				mixedAt  = -1
+				hasASCII bool
+			)
+			for i, r := range word {
+				if r < 0x80 {
+					hasASCII = true
+				}
+				if !unicode.IsLetter(r) {
+					continue
+				}
+				sc, ok := scripts[r]
+				if !ok {
+					sc = unidata.ScriptLatin
+					if r >= 0x80 {
+						info, _ := unidata.Find(r)
+						sc = info.Script()
+					}
+					scripts[r] = sc
+				}
+				if sc == unidata.ScriptCommon || sc == unidata.ScriptInherited || slices.Contains(found, sc) {
+					continue
+				}
+				found = append(found, sc)
+				if mixedAt == -1 && len(found) > 1 {
+					mixedAt = start + i
+				}
+			}
+			if mixedAt > -1 && !slices.ContainsFunc(allowedScripts, func(allow []unidata.Script) bool {
+				return !slices.ContainsFunc(found, func(sc unidata.Script) bool { return !slices.Contains(allow, sc) })
+			}) {
+				names := make([]string, 0, len(found))
+				for _, sc := range found {
+					names = append(names, unidata.Scripts[sc].Name)
+				}
+				add(mixedAt, "mixed-script", fmt.Sprintf("%q mixes %s", string(word), strings.Join(names, " and ")))
+			}
+
+			if hasASCII {
+				for i, r := range word {
+					if r < 0x80 || seq[start+i] != nil {
+						continue
+					}
+					info, _ := unidata.Find(r)
+					if c := info.Confusable(); c != "" {
+						add(start+i, "confusable", fmt.Sprintf("%s %s looks like %q in %q",
+							info.FormatCodepoint(), info.Name(), c, string(word)))
+					}
+				}
+			}
+			start = end
+		}
+		offset += len(line)
+	}
+
+	slices.SortStableFunc(problems, func(a, b auditProblem) int { return a.Offset - b.Offset })
+	return problems
+}
+
+// auditFix removes all problems that can be safely fixed from text, and marks
+// them as fixed.
+func auditFix(text []byte, problems []auditProblem) []byte {
+	var (
+		fixed = make([]byte, 0, len(text))
+		prev  int
+	)
+	for i, p := range problems {
+		if !p.fixable() || p.Offset < prev {
+			continue
+		}
+		fixed = append(fixed, text[prev:p.Offset]...)
+		prev = p.Offset + p.size
+		problems[i].Fixed = true
+	}
+	return append(fixed, text[prev:]...)
+}
+
+func audit(paths []string, conf auditConfig, as printAs) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var problems []auditProblem
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if bytes.IndexByte(text[:min(len(text), 8000)], 0) > -1 { // Binary file.
+				return nil
+			}
+
+			p := auditText(path, text, conf)
+			if conf.fix && slices.ContainsFunc(p, auditProblem.fixable) {
+				st, err := os.Stat(path)
+				if err != nil {
+					return err
+				}
+				err = os.WriteFile(path, auditFix(text, p), st.Mode())
+				if err != nil {
+					return err
+				}
+			}
+			problems = append(problems, p...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	switch as {
+	case printAsJSON, printAsJSONCompact:
+		if problems == nil {
+			problems = []auditProblem{}
+		}
+		j, err := json.MarshalIndent(problems, "", "\t")
+		if as == printAsJSONCompact {
+			j, err = json.Marshal(problems)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(zli.Stdout, string(j))
+	case printAsSARIF, printAsSARIFCompact:
+		err := printSARIF(problems)
+		if err != nil {
+			return err
+		}
+	default:
+		for _, p := range problems {
+			fixed := ""
+			if p.Fixed {
+				fixed = " (fixed)"
+			}
+			fmt.Fprintf(zli.Stdout, "%s:%d:%d: %s: %s%s\n", p.Path, p.Line, p.Col, p.Rule, p.Message, fixed)
+		}
+	}
+
+	var n int
+	for _, p := range problems {
+		if !p.Fixed {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("%d problems", n)
+	}
+	return nil
+}
+
+// printSARIF prints the problems as SARIF 2.1.0, which is understood by
+// GitHub code scanning and various other tools.
+func printSARIF(problems []auditProblem) error {
+	type (
+		text   map[string]string
+		result struct {
+			RuleID    string `json:"ruleId"`
+			Level     string `json:"level"`
+			Message   text   `json:"message"`
+			Locations []any  `json:"locations"`
+		}
+	)
+
+	rules := make([]map[string]any, 0, len(auditRules))
+	for _, r := range auditRules {
+		rules = append(rules, map[string]any{"id": r.name, "shortDescription": text{"text": r.desc}})
+	}
+	results := make([]result, 0, len(problems))
+	for _, p := range problems {
+		if p.Fixed {
+			continue
+		}
+		results = append(results, result{
+			RuleID:  p.Rule,
+			Level:   "error",
+			Message: text{"text": p.Message},
+			Locations: []any{map[string]any{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]any{"uri": filepath.ToSlash(p.Path)},
+					"region":           map[string]any{"startLine": p.Line, "startColumn": p.runeCol, "byteOffset": p.Offset},
+				},
+			}},
+		})
+	}
+
+	j, err := json.MarshalIndent(map[string]any{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "uni",
+				"informationUri": "https://github.com/arp242/uni",
+				"rules":          rules,
+			}},
+			"columnKind": "unicodeCodePoints",
+			"results":    results,
+		}},
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(zli.Stdout, string(j))
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Set from the -copy and -copy-format flags.
+var (
+	copyLines  bool     // Collect the text to copy in Format.
+	copyFormat string   // Format for the text to copy; empty for the character or emoji.
+	copied     []string // Text to copy, collected by Format.
+)
+
+// copyText gets the text to copy for a line: the character or emoji, or the
+// columns from -copy-format.
+func copyText(cp rune, columns map[string]string) string {
+	if copyFormat != "" {
+		return reFindCols.ReplaceAllStringFunc(copyFormat, func(m string) string {
+			return columns[reFindCols.FindStringSubmatch(m)[1]]
+		})
+	}
+	switch {
+	case columns["emoji"] != "":
+		return columns["emoji"]
+	case cp > 0:
+		// Not the char column, as that can have a dotted circle or control
+		// picture.
+		return string(cp)
+	case columns["text"] != "":
+		return columns["text"]
+	}
+	return columns["char"]
+}
+
+// osc52 gets the OSC 52 escape sequence to set the clipboard. Inside tmux it's
+// wrapped in a passthrough sequence, as tmux doesn't pass it on by default.
+func osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if os.Getenv("TMUX") != "" {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+var errNoClipboard = errors.New("copy to clipboard: no terminal and no clipboard program found; install wl-copy, xclip, or xsel")
+
+// copyToClipboard copies the text to the clipboard.
+//
+// This writes an OSC 52 escape sequence to the terminal, which works over SSH
+// and in tmux. Not every terminal supports this and there is no way to know if
+// it worked, so the text is also copied with wl-copy (if running on Wayland),
+// xclip, xsel (if running on X), or pbcopy if one of them exists.
+func copyToClipboard(text string) error {
+	sent := false
+	if tty, err := os.OpenFile(ttyPath, os.O_WRONLY, 0); err == nil {
+		_, err = tty.WriteString(osc52(text))
+		tty.Close()
+		sent = err == nil
+	}
+
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		cmds = append(cmds,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+	cmds = append(cmds, []string{"pbcopy"})
+
+	for _, c := range cmds {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		// wl-copy and xclip fork to serve the clipboard, and the child keeps
+		// stdout and stderr open; reading from them would never finish.
+		stderr := new(bytes.Buffer)
+		if c[0] == "xsel" || c[0] == "pbcopy" {
+			cmd.Stderr = stderr
+		}
+		if err := cmd.Run(); err != nil && !sent {
+			return fmt.Errorf("copy to clipboard: %s: %w: %s", c[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	if !sent {
+		return errNoClipboard
+	}
+	return nil
+}
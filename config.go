@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// config is the configuration file, with the sections as a map; the global
+// section is "".
+//
+//	# Defaults for all commands.
+//	tone = dark
+//
+//	[emoji]
+//	format = %(emoji) %(name)
+//
+//	[aliases]
+//	arrows = block:arrows block:supplemental-arrows-a
+//
+//	[formats]
+//	mine = %(char q) %(cpoint) %(name)
+type config struct {
+	path     string
+	sections map[string]map[string]string
+}
+
+// Keys that can be used in the global and command sections; these set the
+// default for the flag with the same name.
+var configKeys = []string{"format", "as", "or", "tone", "gender", "hair", "direction", "shortcodes", "pua"}
+
+// Commands that print codepoints with defaultFormat; the format in the global
+// section only applies to these, as the others have different columns.
+var codepointCommands = []string{"identify", "search", "print", "recent", "fav"}
+
+// configPath gets the path to the configuration file:
+// $XDG_CONFIG_HOME/uni/config, or ~/.config/uni/config if that's not set.
+func configPath() (string, error) {
+	if d := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(d) {
+		return filepath.Join(d, "uni", "config"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "uni", "config"), nil
+}
+
+// readConfig reads the configuration file. It's not an error if it doesn't
+// exist.
+func readConfig() (config, error) {
+	c := config{sections: map[string]map[string]string{"": {}}}
+	var err error
+	c.path, err = configPath()
+	if err != nil {
+		return c, err
+	}
+	fp, err := os.Open(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+	defer fp.Close()
+
+	var (
+		scan    = bufio.NewScanner(fp)
+		lineno  int
+		section string
+	)
+	for scan.Scan() {
+		lineno++
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return c, fmt.Errorf("%s:%d: no ] in section name: %q", c.path, lineno, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section != "aliases" && section != "formats" && !slices.Contains(commands, section) {
+				return c, fmt.Errorf("%s:%d: unknown section: %q", c.path, lineno, section)
+			}
+			if c.sections[section] == nil {
+				c.sections[section] = make(map[string]string)
+			}
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return c, fmt.Errorf("%s:%d: not in the form of key = value: %q", c.path, lineno, line)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if section != "aliases" && section != "formats" {
+			if !slices.Contains(configKeys, k) {
+				return c, fmt.Errorf("%s:%d: unknown key: %q", c.path, lineno, k)
+			}
+			if _, err := strconv.ParseBool(v); k == "or" && err != nil {
+				return c, fmt.Errorf("%s:%d: or must be true or false, not %q", c.path, lineno, v)
+			}
+		}
+		c.sections[section][k] = v
+	}
+	return c, scan.Err()
+}
+
+// get a value from the command's section, falling back to the global section.
+func (c config) get(cmd, key string) (string, bool) {
+	if v, ok := c.sections[cmd][key]; ok {
+		return v, true
+	}
+	if key == "format" && !slices.Contains(codepointCommands, cmd) {
+		return "", false
+	}
+	v, ok := c.sections[""][key]
+	return v, ok
+}
+
+// confFlag is a flag with the default from the configuration file.
+type confFlag struct {
+	set bool
+	val string
+}
+
+func (f confFlag) Set() bool      { return f.set }
+func (f confFlag) String() string { return f.val }
+
+// String gets the flag, or the value from the configuration if the flag isn't
+// on the commandline.
+func (c config) String(cmd, key string, f fs) confFlag {
+	if f.Set() {
+		return confFlag{true, f.String()}
+	}
+	if v, ok := c.get(cmd, key); ok {
+		return confFlag{true, v}
+	}
+	return confFlag{false, f.String()}
+}
+
+// Bool gets the flag, or the value from the configuration if the flag isn't on
+// the commandline.
+func (c config) Bool(cmd, key string, f fb) bool {
+	if f.Set() {
+		return f.Bool()
+	}
+	v, _ := c.get(cmd, key)
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// preset gets the format for a "@name" preset from the [formats] section; any
+// other format is returned as-is.
+func (c config) preset(format string) (string, error) {
+	if !strings.HasPrefix(format, "@") {
+		return format, nil
+	}
+	p, ok := c.sections["formats"][format[1:]]
+	if !ok {
+		return "", fmt.Errorf("-format flag: no preset %q in %s", format, c.path)
+	}
+	return p, nil
+}
+
+// expandAliases replaces every "@name" argument with the query from the
+// [aliases] section.
+func (c config) expandAliases(args []string) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+	for _, a := range args {
+		if !strings.HasPrefix(a, "@") || len(a) == 1 {
+			expanded = append(expanded, a)
+			continue
+		}
+		q, ok := c.sections["aliases"][a[1:]]
+		if !ok {
+			return nil, fmt.Errorf("no alias %q in %s", a, c.path)
+		}
+		expanded = append(expanded, strings.Fields(q)...)
+	}
+	return expanded, nil
+}
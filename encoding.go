@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+	"zgo.at/zli"
+)
+
+// Encodings for -encoding; the names are matched case-insensitive and without
+// "-" or "_".
+var encodings = map[string]encoding.Encoding{
+	"utf8":        unicode.UTF8,
+	"latin1":      charmap.ISO8859_1,
+	"iso88591":    charmap.ISO8859_1,
+	"cp1252":      charmap.Windows1252,
+	"windows1252": charmap.Windows1252,
+	"cp437":       charmap.CodePage437,
+	"ibm437":      charmap.CodePage437,
+	"shiftjis":    japanese.ShiftJIS,
+	"sjis":        japanese.ShiftJIS,
+	"gb18030":     simplifiedchinese.GB18030,
+	"koi8r":       charmap.KOI8R,
+	"utf16":       unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"utf16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	"utf32":       utf32.UTF32(utf32.BigEndian, utf32.UseBOM),
+	"utf32le":     utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM),
+	"utf32be":     utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM),
+}
+
+func parseEncodingFlag(enc string) encoding.Encoding {
+	if enc == "" {
+		return nil
+	}
+	e, ok := encodings[strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(enc))]
+	if !ok {
+		zli.Fatalf("invalid value for -encoding: %q", enc)
+	}
+	return e
+}
+
+// Byte order marks for UTF-16 and UTF-32; UTF-32 LE needs to be checked before
+// UTF-16 LE.
+var boms = []struct {
+	bom []byte
+	enc encoding.Encoding
+}{
+	{[]byte{0xff, 0xfe, 0, 0}, utf32.UTF32(utf32.LittleEndian, utf32.ExpectBOM)},
+	{[]byte{0, 0, 0xfe, 0xff}, utf32.UTF32(utf32.BigEndian, utf32.ExpectBOM)},
+	{[]byte{0xff, 0xfe}, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)},
+	{[]byte{0xfe, 0xff}, unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)},
+}
+
+// readInput gets the input from the arguments or stdin, decoding it from enc.
+//
+// If enc is nil the input is assumed to be UTF-8, unless stdin starts with a
+// UTF-16 or UTF-32 byte order mark. The UTF-8 BOM is kept, as it's valid
+// UTF-8 that you may want to see.
+func readInput(args []string, enc encoding.Encoding, quiet bool) ([]string, error) {
+	if len(args) > 0 {
+		if enc == nil {
+			return args, nil
+		}
+		for i := range args {
+			d, err := enc.NewDecoder().String(args[i])
+			if err != nil {
+				return nil, fmt.Errorf("decoding input: %w", err)
+			}
+			args[i] = d
+		}
+		return args, nil
+	}
+
+	interactive := zli.IsTerminal(os.Stdin.Fd())
+	if !quiet && interactive {
+		fmt.Fprint(zli.Stderr, zli.Program()+": "+zli.StdinMessage)
+	}
+	in, err := io.ReadAll(zli.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("read stdin: %w", err)
+	}
+	if !quiet && interactive {
+		fmt.Fprint(zli.Stderr, "\r")
+	}
+
+	in, err = decodeInput(in, enc)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(bytes.TrimSuffix(in, []byte("\n")))}, nil
+}
+
+// decodeInput decodes in from enc, or from UTF-16 or UTF-32 if enc is nil and
+// in starts with a byte order mark.
+func decodeInput(in []byte, enc encoding.Encoding) ([]byte, error) {
+	if enc == nil {
+		for _, b := range boms {
+			if bytes.HasPrefix(in, b.bom) {
+				enc = b.enc
+				break
+			}
+		}
+	}
+	if enc == nil {
+		return in, nil
+	}
+	in, err := enc.NewDecoder().Bytes(in)
+	if err != nil {
+		return nil, fmt.Errorf("decoding input: %w", err)
+	}
+	return in, nil
+}
+
+// encodeRune gets the bytes for r in the given encoding, as hex. This is blank
+// if the encoding can't represent r.
+func encodeRune(r rune, enc encoding.Encoding) string {
+	b, err := enc.NewEncoder().String(string(r))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("% x", b)
+}
@@ -15,6 +15,8 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
 	"zgo.at/termtext"
 	"zgo.at/uni/v2/unidata"
 	"zgo.at/zli"
@@ -64,9 +66,12 @@ type Format struct {
 	re        *regexp.Regexp // Cached regexp for format.
 	cols      []column       // Columns we know about.
 	colNames  []string
-	lines     []line // Processed lines, to be printed.
-	autoalign []int  // Max line lengths for autoalign.
-	ntrim     int    // Number of columns with "trim"
+	lines     []line    // Processed lines, to be printed.
+	autoalign []int     // Max line lengths for autoalign.
+	ntrim     int       // Number of columns with "trim"
+	stream    io.Writer // Print lines as they're added; see Stream().
+	nstream   int       // Number of lines printed to stream.
+	copy      bool      // Collect text to copy to the clipboard in copied.
 
 	tblData []unidata.Codepoint
 }
@@ -74,6 +79,7 @@ type Format struct {
 type line struct {
 	cp   rune
 	cols []string
+	copy string
 }
 
 var reFindCols = regexp.MustCompile(`%\((.*?)(?: .+?)?\)`)
@@ -88,22 +94,30 @@ const (
 	printAsJSONCompact
 	printAsTable
 	printAsTableCompact
+	printAsSARIF // Only for audit.
+	printAsSARIFCompact
 )
 
 func header(h string) string {
 	h = strings.ReplaceAll(h, "-", " ")
 	switch h {
-	case "utf8", "utf16", "utf16le", "utf16be", "html", "xml", "json", "cldr":
+	case "utf8", "utf16", "utf16le", "utf16be", "html", "xml", "json", "cldr", "eaw":
 		return strings.ToUpper(h)
 	case "cpoint":
 		return "CPoint"
+	case "cpoints":
+		return "CPoints"
+	case "cellcol":
+		return "CellCol"
+	case "emoji_version":
+		return "Version"
 	default:
 		return zstring.UpperFirst(h)
 	}
 }
 
 func NewFormat(format string, as printAs, knownCols ...string) (*Format, error) {
-	f := Format{format: format, as: as}
+	f := Format{format: format, as: as, copy: copyLines}
 
 	if as == printAsTable || as == printAsTableCompact {
 		// Don't need all the rest of the logic.
@@ -126,6 +140,7 @@ func NewFormat(format string, as printAs, knownCols ...string) (*Format, error)
 			return nil, fmt.Errorf("-format flag: unknown placeholder: %q", c.name)
 		}
 		cols = append(cols, c.name)
+		f.colNames = append(f.colNames, c.name)
 
 		if f.json() {
 			h[c.name] = c.name
@@ -134,6 +149,16 @@ func NewFormat(format string, as printAs, knownCols ...string) (*Format, error)
 		}
 	}
 
+	// toLine() only sets the columns in colNames.
+	if f.copy {
+		for _, m := range reFindCols.FindAllStringSubmatch(copyFormat, -1) {
+			if !slices.Contains(knownCols, m[1]) {
+				return nil, fmt.Errorf("-copy-format flag: unknown placeholder: %q", m[1])
+			}
+			f.colNames = append(f.colNames, m[1])
+		}
+	}
+
 	h["wide_padding"] = " "
 	h["tab"] = tabOrSpace()
 
@@ -233,6 +258,9 @@ func (f *Format) Line(cp rune, columns map[string]string) error {
 	}
 
 	cols := line{cp: cp, cols: make([]string, len(f.cols))}
+	if f.copy && cp > -1 {
+		cols.copy = copyText(cp, columns)
+	}
 	for i, c := range f.cols {
 		cols.cols[i] = columns[c.name]
 		if c.width == alignAuto {
@@ -245,10 +273,38 @@ func (f *Format) Line(cp rune, columns map[string]string) error {
 			}
 		}
 	}
+	if f.stream != nil {
+		if f.copy && cp > -1 {
+			copied = append(copied, cols.copy)
+		}
+		f.printLine(f.stream, f.nstream, cols)
+		f.nstream++
+		return nil
+	}
 	f.lines = append(f.lines, cols)
 	return nil
 }
 
+// Stream prints lines to out as soon as they're added with Line(), rather than
+// buffering them until Print(). Columns aligned with "auto" only use the widths
+// of the lines seen so far.
+//
+// This does nothing for JSON and tables, which always need all the lines.
+func (f *Format) Stream(out io.Writer) {
+	if f.json() || f.tbl() {
+		return
+	}
+	f.stream = out
+	for _, l := range f.lines {
+		if f.copy && l.cp > -1 {
+			copied = append(copied, l.copy)
+		}
+		f.printLine(out, f.nstream, l)
+		f.nstream++
+	}
+	f.lines = nil
+}
+
 // SortCodepoint sorts by codepoint. When this is not called it prints in the
 // order they were added to Format.
 func (f *Format) SortCodepoint() {
@@ -339,7 +395,7 @@ func (f *Format) printTbl(out io.Writer) {
 		head   = 4
 	)
 	for _, c := range f.tblData {
-		if w := c.Width(); w == unidata.WidthFullWidth || w == unidata.WidthWide || w == unidata.WidthAmbiguous {
+		if c.IsWide() {
 			wide = true
 		}
 		tblMap[c.Codepoint] = c.Display()
@@ -411,49 +467,65 @@ func (f *Format) printTbl(out io.Writer) {
 }
 
 func (f *Format) Print(out io.Writer) {
+	if f.copy {
+		for _, l := range f.lines {
+			if l.cp > -1 {
+				copied = append(copied, l.copy)
+			}
+		}
+	}
+
 	if f.json() {
 		f.printJSON(out)
 		return
 	}
 	if f.tbl() {
 		f.printTbl(out)
+		if f.copy {
+			for _, c := range f.tblData {
+				copied = append(copied, string(c.Codepoint))
+			}
+		}
 		return
 	}
 
 	for lineno, l := range f.lines {
-		line := f.format
+		f.printLine(out, lineno, l)
+	}
+}
 
+func (f *Format) printLine(out io.Writer, lineno int, l line) {
+	line := f.format
+	for i, text := range l.cols {
+		m := f.re.FindAllString(line, 1)
+		line = strings.Replace(line, m[0], f.fmtPlaceholder(i, lineno, text, 0), 1)
+	}
+
+	// This line is too long and we want to trim: reformat the lot.
+	// TODO: this can be a bit more efficient: we know the column widths and
+	// text already, but this is easier.
+	w := termtext.Width(line)
+	if f.ntrim > 0 && w > termWidth {
+		var (
+			tooLongBy = w - termWidth
+			t         = make([]int, len(f.cols))
+		)
 		for i, text := range l.cols {
-			m := f.re.FindAllString(line, 1)
-			line = strings.Replace(line, m[0], f.fmtPlaceholder(i, lineno, text, 0), 1)
-		}
-
-		// This line is too long and we want to trim: reformat the lot.
-		// TODO: this can be a bit more efficient: we know the column widths and
-		// text already, but this is easier.
-		w := termtext.Width(line)
-		if f.ntrim > 0 && w > termWidth {
-			var (
-				tooLongBy = w - termWidth
-				t         = make([]int, len(f.cols))
-			)
-			for i, text := range l.cols {
-				if f.cols[i].trim {
-					t[i] = termtext.Width(text)
-				}
-			}
-			trim := nratio(tooLongBy, t...)
-
-			line = f.format
-			for i, text := range l.cols {
-				m := f.re.FindAllString(line, 1)
-				line = strings.Replace(line, m[0], f.fmtPlaceholder(i, lineno, text, trim[i]-1), 1)
+			if f.cols[i].trim {
+				t[i] = termtext.Width(text)
 			}
 		}
+		trim := nratio(tooLongBy, t...)
 
-		line = strings.TrimRight(line, " ")
-		out.Write(append([]byte(line), '\n'))
+		line = f.format
+		for i, text := range l.cols {
+			m := f.re.FindAllString(line, 1)
+			line = strings.Replace(line, m[0], f.fmtPlaceholder(i, lineno, text, trim[i]-1), 1)
+		}
 	}
+
+	line = strings.TrimRight(line, " ")
+	out.Write(append([]byte(line), '\n'))
 }
 
 // nratio subtracts "sub" from all the numbers in "nums" proportionally. That
@@ -492,11 +564,14 @@ func (f *Format) fmtPlaceholder(i, lineno int, text string, applyTrim int) strin
 	if w == alignAuto {
 		w = f.autoalign[i]
 	}
-	switch c.align {
-	case alignLeft:
-		text = zstring.AlignLeft(text, w)
-	case alignRight:
-		text = zstring.AlignRight(text, w)
+	// Pad to the display width, which is also what autoalign uses.
+	if pad := w - termtext.Width(text); pad > 0 {
+		switch c.align {
+		case alignLeft:
+			text += strings.Repeat(" ", pad)
+		case alignRight:
+			text = strings.Repeat(" ", pad) + text
+		}
 	}
 	if c.fill > 0 {
 		if f.as == printAsListCompact || lineno > 0 {
@@ -520,7 +595,22 @@ func (f *Format) String() string {
 var knownColumns = []string{"char", "wide_padding", "cpoint", "dec", "hex",
 	"oct", "bin", "utf8", "utf16be", "utf16le", "html", "xml", "json", "keysym",
 	"digraph", "name", "cat", "block", "plane", "width", "cells", "props", "script",
-	"unicode", "aliases", "refs"}
+	"unicode", "aliases", "refs", "region", "base", "tone", "gender", "utf32",
+	"go", "python", "js", "rust", "c", "java", "css", "url", "sql", "latin1",
+	"cp1252", "sjis", "offset", "line", "col", "cellcol"}
+
+// Columns for unidata.Codepoint.Escape().
+var escapeColumns = map[string]unidata.EscapeLang{
+	"go":     unidata.EscapeGo,
+	"python": unidata.EscapePython,
+	"js":     unidata.EscapeJS,
+	"rust":   unidata.EscapeRust,
+	"c":      unidata.EscapeC,
+	"java":   unidata.EscapeJava,
+	"css":    unidata.EscapeCSS,
+	"url":    unidata.EscapeURL,
+	"sql":    unidata.EscapeSQL,
+}
 
 func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 	if f.tbl() {
@@ -540,9 +630,22 @@ func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 			"utf8":         fmt.Sprintf("% x", info.UTF8()),
 			"utf16be":      fmt.Sprintf("% x", info.UTF16(true)),
 			"utf16le":      fmt.Sprintf("% x", info.UTF16(false)),
+			"utf32":        fmt.Sprintf("% x", info.UTF32(true)),
+			"latin1":       encodeRune(info.Codepoint, charmap.ISO8859_1),
+			"cp1252":       encodeRune(info.Codepoint, charmap.Windows1252),
+			"sjis":         encodeRune(info.Codepoint, japanese.ShiftJIS),
 			"html":         info.HTML(),
 			"xml":          info.XML(),
 			"json":         info.JSON(),
+			"go":           info.Escape(unidata.EscapeGo),
+			"python":       info.Escape(unidata.EscapePython),
+			"js":           info.Escape(unidata.EscapeJS),
+			"rust":         info.Escape(unidata.EscapeRust),
+			"c":            info.Escape(unidata.EscapeC),
+			"java":         info.Escape(unidata.EscapeJava),
+			"css":          info.Escape(unidata.EscapeCSS),
+			"url":          info.Escape(unidata.EscapeURL),
+			"sql":          info.Escape(unidata.EscapeSQL),
 			"keysym":       info.KeySym(),
 			"digraph":      info.Digraph(),
 			"name":         info.Name(),
@@ -554,15 +657,18 @@ func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 			"props":        info.Properties().String(),
 			"script":       info.Script().String(),
 			"unicode":      info.Unicode().String(),
-			"aliases":      strings.Join(info.Aliases(), ", "),
+			"aliases":      strings.Join(aliases(info), ", "),
 			"refs":         strings.Join(info.Refs(), ", "),
-		}
-	}
-
-	if f.colNames == nil {
-		f.colNames = make([]string, len(f.cols))
-		for _, c := range f.cols {
-			f.colNames = append(f.colNames, c.name)
+			// Set by identify, as they need the full sequence.
+			"region": "",
+			"base":   "",
+			"tone":   "",
+			"gender": "",
+			// Set by identify, as they depend on the position in the input.
+			"offset":  "",
+			"line":    "",
+			"col":     "",
+			"cellcol": "",
 		}
 	}
 
@@ -597,6 +703,18 @@ func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 	if slices.Contains(f.colNames, "utf16le") {
 		cols["utf16le"] = fmt.Sprintf("% x", info.UTF16(false))
 	}
+	if slices.Contains(f.colNames, "utf32") {
+		cols["utf32"] = fmt.Sprintf("% x", info.UTF32(true))
+	}
+	if slices.Contains(f.colNames, "latin1") {
+		cols["latin1"] = encodeRune(info.Codepoint, charmap.ISO8859_1)
+	}
+	if slices.Contains(f.colNames, "cp1252") {
+		cols["cp1252"] = encodeRune(info.Codepoint, charmap.Windows1252)
+	}
+	if slices.Contains(f.colNames, "sjis") {
+		cols["sjis"] = encodeRune(info.Codepoint, japanese.ShiftJIS)
+	}
 	if slices.Contains(f.colNames, "html") {
 		cols["html"] = info.HTML()
 	}
@@ -606,6 +724,11 @@ func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 	if slices.Contains(f.colNames, "json") {
 		cols["json"] = info.JSON()
 	}
+	for c, l := range escapeColumns {
+		if slices.Contains(f.colNames, c) {
+			cols[c] = info.Escape(l)
+		}
+	}
 	if slices.Contains(f.colNames, "keysym") {
 		cols["keysym"] = info.KeySym()
 	}
@@ -640,11 +763,16 @@ func (f *Format) toLine(info unidata.Codepoint, raw bool) map[string]string {
 		cols["unicode"] = info.Unicode().String()
 	}
 	if slices.Contains(f.colNames, "aliases") {
-		cols["aliases"] = strings.Join(info.Aliases(), ", ")
+		cols["aliases"] = strings.Join(aliases(info), ", ")
 	}
 	if slices.Contains(f.colNames, "refs") {
 		cols["refs"] = strings.Join(info.Refs(), ", ")
 	}
+	for _, c := range []string{"region", "base", "tone", "gender", "offset", "line", "col", "cellcol"} {
+		if slices.Contains(f.colNames, c) {
+			cols[c] = ""
+		}
+	}
 	return cols
 }
 
@@ -670,7 +798,7 @@ func tabOrSpace() string {
 }
 
 func widePadding(info unidata.Codepoint) string {
-	if info.Width() != unidata.WidthFullWidth && info.Width() != unidata.WidthWide {
+	if !info.IsWide() {
 		return " "
 	}
 	return ""
@@ -1,12 +1,15 @@
 module zgo.at/uni/v2
 
-go 1.24
+go 1.24.0
 
 require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/term v0.39.0
+	golang.org/x/text v0.34.0
 	zgo.at/runewidth v0.1.0
 	zgo.at/termtext v1.5.0
 	zgo.at/zli v0.0.0-20250704045222-08cb210424f2
 	zgo.at/zstd v0.0.0-20251128053228-ec259dea6715
 )
 
-require github.com/rivo/uniseg v0.4.7 // indirect
+require golang.org/x/sys v0.40.0
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// utf8Seq is a group of bytes in a hexdump: either a valid UTF-8 sequence, or
+// an invalid sequence with the reason why it's invalid.
+type utf8Seq struct {
+	bytes   []byte
+	r       rune
+	invalid string
+}
+
+// nextUTF8 gets the next UTF-8 sequence from b, which should contain at least
+// 6 bytes if there are that many remaining.
+//
+// Unlike utf8.DecodeRune() this decodes sequences that are structurally
+// correct but invalid (overlong, surrogates, out of range) as a group, so it
+// can report what they would have encoded.
+func nextUTF8(b []byte) utf8Seq {
+	lead := b[0]
+	var (
+		n    int  // Number of continuation bytes.
+		v    rune // Decoded value.
+		minV rune // Minimum value for this length, to detect overlong sequences.
+	)
+	switch {
+	case lead < 0x80:
+		return utf8Seq{bytes: b[:1], r: rune(lead)}
+	case lead < 0xc0:
+		return utf8Seq{bytes: b[:1], invalid: "unexpected continuation byte"}
+	case lead < 0xe0:
+		n, v, minV = 1, rune(lead&0x1f), 0x80
+	case lead < 0xf0:
+		n, v, minV = 2, rune(lead&0x0f), 0x800
+	case lead < 0xf8:
+		n, v, minV = 3, rune(lead&0x07), 0x10000
+	default:
+		return utf8Seq{bytes: b[:1], invalid: fmt.Sprintf("byte 0x%02x never appears in UTF-8", lead)}
+	}
+
+	for i := 1; i <= n; i++ {
+		if i >= len(b) || b[i]&0xc0 != 0x80 {
+			return utf8Seq{bytes: b[:i], invalid: fmt.Sprintf("truncated sequence: expected %d bytes, got %d", n+1, i)}
+		}
+		v = v<<6 | rune(b[i]&0x3f)
+	}
+
+	seq := utf8Seq{bytes: b[:n+1], r: v}
+	switch {
+	case v < minV:
+		seq.invalid = fmt.Sprintf("overlong encoding of U+%04X", v)
+	case v > 0x10ffff:
+		seq.invalid = fmt.Sprintf("out of range: U+%04X is above U+10FFFF", v)
+	case v >= 0xd800 && v <= 0xdbff:
+		// High surrogate followed by a low surrogate is how CESU-8 and Java's
+		// "modified UTF-8" encode codepoints outside the BMP.
+		if len(b) >= 6 {
+			if low := nextUTF8(b[3:]); len(low.bytes) == 3 && low.r >= 0xdc00 && low.r <= 0xdfff {
+				seq.bytes = b[:6]
+				seq.invalid = fmt.Sprintf("surrogate pair U+%04X U+%04X encoded in UTF-8 (CESU-8) for U+%04X",
+					v, low.r, 0x10000+(v-0xd800)<<10+(low.r-0xdc00))
+				return seq
+			}
+		}
+		fallthrough
+	case v >= 0xd800 && v <= 0xdfff:
+		seq.invalid = fmt.Sprintf("surrogate U+%04X encoded in UTF-8 (WTF-8)", v)
+	}
+	return seq
+}
+
+// hexdump prints every UTF-8 sequence in the input on its own line, annotated
+// with the codepoint, or the reason why it's invalid. The input is read from
+// path, or the args if path is "", or stdin if there are no args or path is
+// "-".
+func hexdump(args []string, path string, nonASCII bool) error {
+	var fp io.Reader = zli.Stdin
+	switch {
+	case path != "" && path != "-":
+		fh, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		fp = fh
+	case path == "" && len(args) > 0:
+		fp = strings.NewReader(strings.Join(args, " "))
+	}
+
+	var (
+		r       = bufio.NewReader(fp)
+		offset  int
+		invalid int
+	)
+	for {
+		b, err := r.Peek(6)
+		if len(b) == 0 {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+
+		seq := nextUTF8(b)
+		hex := fmt.Sprintf("% x", seq.bytes)
+		if seq.invalid != "" {
+			invalid++
+			msg := "invalid: " + seq.invalid
+			if isTerm {
+				msg = zli.Colorize(msg, zli.Red)
+			}
+			fmt.Fprintf(zli.Stdout, "%08x  %-11s  %s\n", offset, hex, msg)
+		} else if !nonASCII || seq.r >= 0x80 {
+			info, _ := unidata.Find(seq.r)
+			fmt.Fprintf(zli.Stdout, "%08x  %-11s  %s%s %s %s\n", offset, hex,
+				info.Display(), widePadding(info), info.FormatCodepoint(), info.Name())
+		}
+
+		offset += len(seq.bytes)
+		r.Discard(len(seq.bytes))
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid UTF-8 sequences", invalid)
+	}
+	return nil
+}
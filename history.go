@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rivo/uniseg"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// Maximum number of entries to keep in the history file.
+const historyMax = 1000
+
+// stateDir gets the directory for the history and favourites:
+// $XDG_STATE_HOME/uni, or ~/.local/state/uni if that's not set.
+func stateDir() (string, error) {
+	if d := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(d) {
+		return filepath.Join(d, "uni"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "uni"), nil
+}
+
+// readLines reads all lines from a file in the state directory. It's not an
+// error if the file doesn't exist.
+func readLines(name string) ([]string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer fp.Close()
+
+	var (
+		lines []string
+		scan  = bufio.NewScanner(fp)
+	)
+	for scan.Scan() {
+		if l := scan.Text(); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines, scan.Err()
+}
+
+// writeLines writes the lines to a file in the state directory.
+func writeLines(name string, lines []string) error {
+	dir, err := stateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+	return os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0o644)
+}
+
+// validItem reports if the text can be stored in the history or favourites;
+// every entry is one line.
+func validItem(s string) bool {
+	return s != "" && !strings.ContainsAny(s, "\r\n")
+}
+
+// recordHistory adds the characters or emojis to the history file, which is a
+// list of "unix-time<tab>text" lines.
+func recordHistory(items []string) error {
+	lines, err := readLines("history")
+	if err != nil {
+		return err
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	for _, it := range items {
+		if validItem(it) {
+			lines = append(lines, now+"\t"+it)
+		}
+	}
+	if len(lines) > historyMax {
+		lines = lines[len(lines)-historyMax:]
+	}
+	return writeLines("history", lines)
+}
+
+// recentItems gets the unique items from the history, most recently used
+// first.
+func recentItems() ([]string, error) {
+	lines, err := readLines("history")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, 0, len(lines))
+	for _, l := range slices.Backward(lines) {
+		_, it, ok := strings.Cut(l, "\t")
+		if ok && !slices.Contains(items, it) {
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+// recentRanks gets the position of every item in the history, with 0 being the
+// most recently used. Errors are ignored, as this is just to sort search
+// results.
+func recentRanks() map[string]int {
+	items, _ := recentItems()
+	ranks := make(map[string]int, len(items))
+	for i, it := range items {
+		ranks[it] = i
+	}
+	return ranks
+}
+
+// compareRecent sorts recently used items before everything else, most recent
+// first. It returns 0 if neither was used.
+func compareRecent(ranks map[string]int, a, b string) int {
+	ra, okA := ranks[a]
+	rb, okB := ranks[b]
+	switch {
+	case okA && okB:
+		return ra - rb
+	case okA:
+		return -1
+	case okB:
+		return 1
+	}
+	return 0
+}
+
+// printItems prints the history or favourites with Format. Emoji sequences are
+// printed on one line, with the name of the emoji.
+func printItems(items []string, format string, raw bool, as printAs) error {
+	if len(items) == 0 {
+		return errNoMatches
+	}
+	f, err := NewFormat(format, as, knownColumns...)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		runes := []rune(it)
+		info, _ := unidata.Find(runes[0])
+		cols := f.toLine(info, raw)
+		if len(runes) > 1 && cols != nil {
+			cp := make([]string, 0, len(runes))
+			for _, r := range runes {
+				cp = append(cp, fmt.Sprintf("U+%04X", r))
+			}
+			cols["char"], cols["cpoint"] = it, strings.Join(cp, " ")
+			if e, mods, ok := unidata.ParseEmoji(it); ok {
+				cols["name"] = e.With(mods).Name
+			}
+			cols["emoji"] = it // For -copy.
+		}
+		f.Line(runes[0], cols)
+	}
+	f.Print(zli.Stdout)
+	return nil
+}
+
+// recent prints the most recently used characters and emojis.
+func recent(args []string, format string, raw bool, as printAs) error {
+	n := 20
+	if len(args) > 0 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("recent: not a positive number: %q", args[0])
+		}
+	}
+	items, err := recentItems()
+	if err != nil {
+		return err
+	}
+	return printItems(items[:min(n, len(items))], format, raw, as)
+}
+
+// fav adds, removes, or lists favourite characters and emojis.
+func fav(args []string, format string, raw bool, as printAs) error {
+	sub := "list"
+	if len(args) > 0 {
+		var err error
+		sub, err = match(args[0], "add", "rm", "list")
+		if err != nil {
+			return fmt.Errorf("fav: %w", err)
+		}
+		args = args[1:]
+	}
+
+	favs, err := readLines("favourites")
+	if err != nil {
+		return err
+	}
+	if sub == "list" {
+		return printItems(favs, format, raw, as)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("fav %s: need at least one character or emoji", sub)
+	}
+
+	// Every grapheme cluster is one favourite, so "uni fav add €£" adds two.
+	var items []string
+	for _, a := range args {
+		g := uniseg.NewGraphemes(a)
+		for g.Next() {
+			if it := g.Str(); validItem(strings.TrimSpace(it)) {
+				items = append(items, it)
+			}
+		}
+	}
+
+	for _, it := range items {
+		i := slices.Index(favs, it)
+		switch {
+		case sub == "add" && i == -1:
+			favs = append(favs, it)
+		case sub == "rm" && i == -1:
+			return fmt.Errorf("fav rm: not a favourite: %q", it)
+		case sub == "rm":
+			favs = slices.Delete(favs, i, i+1)
+		}
+	}
+	return writeLines("favourites", favs)
+}
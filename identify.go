@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/encoding"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// position is the position of a codepoint in the input.
+type position struct {
+	offset  int // Byte offset, starting at 0.
+	line    int // Line number, starting at 1.
+	col     int // Column in bytes, starting at 1.
+	cellcol int // Column in terminal cells, starting at 1.
+
+	path string // File name, for the "path" column in identify -file.
+}
+
+func newPosition() position { return position{line: 1, col: 1, cellcol: 1} }
+
+// advance the position past r, which is size bytes.
+func (p *position) advance(r rune, size int) {
+	p.offset += size
+	if r == '\n' {
+		p.line, p.col, p.cellcol = p.line+1, 1, 1
+		return
+	}
+	p.col += size
+	if info, ok := unidata.Find(r); ok {
+		p.cellcol += int(info.Cells())
+	}
+}
+
+// filterFunc reports if a codepoint should be printed by identify.
+type filterFunc func(unidata.Codepoint) bool
+
+// parseFilter gets the filter for the -nonascii and -filter flags; this
+// returns nil if there is no filter.
+//
+// The filter is a comma-separated list of codepoints, ranges, categories,
+// blocks, scripts, or properties, using the same syntax as print; a codepoint
+// is printed if it matches any of them.
+func parseFilter(nonASCII bool, filter string) (filterFunc, error) {
+	var match []query
+	for _, q := range strings.Split(filter, ",") {
+		q = strings.TrimSpace(strings.ToLower(q))
+		if q == "" {
+			continue
+		}
+		m, err := parseQuery(q)
+		if err != nil {
+			return nil, fmt.Errorf("-filter: %w", err)
+		}
+		match = append(match, m)
+	}
+
+	if !nonASCII && len(match) == 0 {
+		return nil, nil
+	}
+	return func(info unidata.Codepoint) bool {
+		if nonASCII && info.Codepoint < 0x80 {
+			return false
+		}
+		if len(match) == 0 {
+			return true
+		}
+		return slices.ContainsFunc(match, func(q query) bool { return q.match(info) })
+	}, nil
+}
+
+// identifyText adds all codepoints in text to f, starting at pos.
+func identifyText(f *Format, text string, pos *position, raw bool, filter filterFunc) error {
+	var (
+		runes = make([]rune, 0, len(text))
+		sizes = make([]int, 0, len(text))
+	)
+	for len(text) > 0 {
+		r, s := utf8.DecodeRuneInString(text)
+		runes, sizes = append(runes, r), append(sizes, s)
+		text = text[s:]
+	}
+
+	seq := sequenceColumns(runes)
+	for i, c := range runes {
+		info, ok := unidata.Find(c)
+		if !ok {
+			return fmt.Errorf("unknown codepoint: U+%.4X", c) // Should never happen.
+		}
+		if filter == nil || filter(info) {
+			line := f.toLine(info, raw)
+			for k, v := range seq[i] {
+				if _, ok := line[k]; ok {
+					line[k] = v
+				}
+			}
+			for k, v := range map[string]int{"offset": pos.offset, "line": pos.line, "col": pos.col, "cellcol": pos.cellcol} {
+				if _, ok := line[k]; ok {
+					line[k] = strconv.Itoa(v)
+				}
+			}
+			if pos.path != "" && line != nil {
+				line["path"] = pos.path
+			}
+			f.Line(info.Codepoint, line)
+		}
+		pos.advance(c, sizes[i])
+	}
+	return nil
+}
+
+// identifyFile identifies all codepoints in a file, or stdin if path is "-".
+//
+// The file is read in chunks and printed as it's read, so it works for large
+// files, even if they have no newlines. Every line is prefixed with
+// "path:line:col: ", which most editors understand.
+func identifyFile(path string, enc encoding.Encoding, format string, raw bool, as printAs, filter filterFunc) error {
+	var (
+		fp   io.Reader = zli.Stdin
+		name           = "(standard input)"
+	)
+	if path != "-" {
+		fh, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		fp, name = fh, path
+	}
+
+	r := bufio.NewReader(fp)
+	if enc == nil {
+		start, _ := r.Peek(4)
+		for _, b := range boms {
+			if strings.HasPrefix(string(start), string(b.bom)) {
+				enc = b.enc
+				break
+			}
+		}
+	}
+	if enc != nil {
+		r = bufio.NewReader(enc.NewDecoder().Reader(r))
+	}
+
+	if as == printAsList {
+		as = printAsListCompact // Header would just get in the way.
+	}
+	// Don't add the name to the format, as it may contain a %.
+	f, err := NewFormat("%(path h):%(line h):%(col h): "+format, as, append(slices.Clone(knownColumns), "path")...)
+	if err != nil {
+		return err
+	}
+	f.Stream(zli.Stdout)
+
+	var (
+		pos  = newPosition()
+		buf  []byte
+		read = make([]byte, readSize)
+	)
+	pos.path = name
+	for {
+		n, readErr := r.Read(read)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return fmt.Errorf("reading %s: %w", name, readErr)
+		}
+		buf = append(buf, read[:n]...)
+		end := len(buf)
+		if readErr == nil {
+			end = splitChunk(buf)
+		}
+		if end > 0 {
+			err := identifyText(f, string(buf[:end]), &pos, raw, filter)
+			if err != nil {
+				return err
+			}
+			buf = append(buf[:0], buf[end:]...)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	f.Print(zli.Stdout)
+	return nil
+}
+
+// Size of the chunks identifyFile reads.
+const readSize = 64 * 1024
+
+// splitChunk gets the end of the text in b that can be identified, which is
+// up to and including the last newline. If there is no newline and b is at
+// least readSize then it's up to the last grapheme cluster, so that sequences
+// such as flags are never split. It returns 0 if more needs to be read first.
+func splitChunk(b []byte) int {
+	if i := bytes.LastIndexByte(b, '\n'); i > -1 {
+		return i + 1
+	}
+	if len(b) < readSize {
+		return 0
+	}
+	var (
+		rest  = b
+		last  int
+		state = -1
+	)
+	for len(rest) > 0 {
+		last = len(b) - len(rest)
+		_, rest, _, state = uniseg.FirstGraphemeCluster(rest, state)
+	}
+	if last == 0 {
+		return len(b)
+	}
+	return last
+}
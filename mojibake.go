@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// Encodings that UTF-8 is commonly decoded as, in order of preference if more
+// than one matches equally well.
+var mojibakeEncodings = []struct {
+	name string
+	enc  *charmap.Charmap
+}{
+	{"cp1252", charmap.Windows1252},
+	{"latin1", charmap.ISO8859_1},
+	{"macroman", charmap.Macintosh},
+	{"cp437", charmap.CodePage437},
+}
+
+// Bytes that aren't defined in cp1252. Most decoders pass these through as the
+// C1 control with the same value, so "Á" (C3 81) becomes "Ã" and U+0081.
+var cp1252Undefined = []rune{0x81, 0x8d, 0x8f, 0x90, 0x9d}
+
+type (
+	// mojibakeFix is a possible repair for mojibake.
+	mojibakeFix struct {
+		encs       []string   // Encodings that were used to decode the UTF-8, in order.
+		fixed      []rune     // Repaired text.
+		changes    []mbChange // Sequences that were changed.
+		confidence float64    // 0 to 1.
+	}
+	mbChange struct {
+		from []rune
+		to   rune
+	}
+)
+
+// findMojibake finds the most likely repair for UTF-8 text that was decoded
+// with the wrong encoding; for example "cafÃ©" instead of "café", or "â€™"
+// instead of "’".
+//
+// All the encodings in mojibakeEncodings are tried, nested up to two times
+// ("ÃƒÂ©" is é decoded as cp1252 twice). This returns nil if there's nothing
+// that looks like mojibake.
+func findMojibake(text string) *mojibakeFix {
+	var (
+		in   = []rune(text)
+		best *mojibakeFix
+	)
+	for _, e1 := range mojibakeEncodings {
+		fixed, orig, changes := repairMojibake(in, nil, e1.enc)
+		if len(changes) == 0 {
+			continue
+		}
+		best = bestMojibake(best, &mojibakeFix{encs: []string{e1.name}, fixed: fixed, changes: changes})
+
+		for _, e2 := range mojibakeEncodings {
+			fixed2, _, changes2 := repairMojibake(fixed, orig, e2.enc)
+			if len(changes2) == 0 {
+				continue
+			}
+			best = bestMojibake(best, &mojibakeFix{encs: []string{e1.name, e2.name}, fixed: fixed2, changes: changes2})
+		}
+	}
+	return best
+}
+
+// bestMojibake sets the confidence on b and returns the best of a and b; a is
+// preferred if they're equally good.
+func bestMojibake(a, b *mojibakeFix) *mojibakeFix {
+	// Codepoints that remain in the output and that often appear in mojibake,
+	// or indicate that the repair went wrong.
+	var (
+		suspicious int
+		broken     bool
+	)
+	for _, r := range b.fixed {
+		switch {
+		case r == utf8.RuneError || (r >= 0x80 && r <= 0x9f):
+			broken = true
+		case strings.ContainsRune("ÃÂâÅÐÑ", r):
+			suspicious++
+		}
+	}
+
+	n := float64(len(b.changes))
+	b.confidence = n / (n + float64(suspicious))
+	if n == 1 { // A single change could be a coincidence.
+		b.confidence *= .8
+	}
+	if broken {
+		b.confidence *= .5
+	}
+	b.confidence = math.Round(b.confidence*100) / 100
+
+	if a == nil || b.confidence > a.confidence ||
+		(b.confidence == a.confidence && len(b.fixed) < len(a.fixed)) {
+		return b
+	}
+	return a
+}
+
+// repairMojibake encodes every codepoint in the text with enc, and decodes
+// valid multi-byte UTF-8 sequences in the result; everything else is left
+// alone.
+//
+// orig is the original text for every codepoint, to track the changes over
+// several passes; it's the same as text if nil. The returned orig is the
+// original text for every codepoint in fixed.
+func repairMojibake(text []rune, orig [][]rune, enc encoding.Encoding) ([]rune, [][]rune, []mbChange) {
+	if orig == nil {
+		orig = make([][]rune, len(text))
+		for i := range text {
+			orig[i] = text[i : i+1]
+		}
+	}
+
+	var (
+		bytes   = make([]byte, len(text))
+		ok      = make([]bool, len(text))
+		encoder = enc.NewEncoder()
+	)
+	for i, r := range text {
+		if r >= 0x80 {
+			b, err := encoder.String(string(r))
+			if err != nil && enc == charmap.Windows1252 && slices.Contains(cp1252Undefined, r) {
+				b, err = string([]byte{byte(r)}), nil
+			}
+			if err != nil || len(b) != 1 {
+				continue
+			}
+			bytes[i], ok[i] = b[0], true
+		}
+	}
+
+	var (
+		fixed     = make([]rune, 0, len(text))
+		fixedOrig = make([][]rune, 0, len(text))
+		changes   []mbChange
+	)
+	for i := 0; i < len(text); i++ {
+		if ok[i] && bytes[i] >= 0xc2 {
+			r, size := utf8.DecodeRune(bytes[i:])
+			if r != utf8.RuneError && size > 1 && !slices.Contains(ok[i:i+size], false) {
+				var from []rune
+				for _, o := range orig[i : i+size] {
+					from = append(from, o...)
+				}
+				fixed, fixedOrig = append(fixed, r), append(fixedOrig, from)
+				changes = append(changes, mbChange{from: from, to: r})
+				i += size - 1
+				continue
+			}
+		}
+		fixed, fixedOrig = append(fixed, text[i]), append(fixedOrig, orig[i])
+	}
+	return fixed, fixedOrig, changes
+}
+
+func mojibake(args []string, fix bool) error {
+	text := strings.Join(args, " ")
+	m := findMojibake(text)
+
+	if fix {
+		if m != nil && m.confidence >= .5 {
+			text = string(m.fixed)
+		}
+		fmt.Fprintln(zli.Stdout, text)
+		return nil
+	}
+	if m == nil {
+		return errNoMatches
+	}
+
+	decoded := "UTF-8 decoded as " + m.encs[0]
+	if len(m.encs) > 1 {
+		decoded += ", and then as " + m.encs[1]
+	}
+	fmt.Fprintf(zli.Stdout, "%s (confidence: %.0f%%)\n\n", decoded, m.confidence*100)
+	fmt.Fprintf(zli.Stdout, "    %s\n    %s\n\n", text, string(m.fixed))
+
+	var (
+		seen = make(map[string]struct{})
+		w    int
+	)
+	for _, c := range m.changes {
+		w = max(w, utf8.RuneCountInString(cpoints(c.from)))
+	}
+	for _, c := range m.changes {
+		if _, ok := seen[string(c.from)]; ok {
+			continue
+		}
+		seen[string(c.from)] = struct{}{}
+
+		info, _ := unidata.Find(c.to)
+		from := cpoints(c.from)
+		fmt.Fprintf(zli.Stdout, "    %s%s → %s %s %s\n", from, strings.Repeat(" ", w-utf8.RuneCountInString(from)),
+			info.Display(), info.FormatCodepoint(), info.Name())
+	}
+	return nil
+}
+
+// cpoints formats the runes as "Ã© (U+00C3 U+00A9)".
+func cpoints(runes []rune) string {
+	cp := make([]string, 0, len(runes))
+	for _, r := range runes {
+		cp = append(cp, fmt.Sprintf("U+%04X", r))
+	}
+	return string(runes) + " (" + strings.Join(cp, " ") + ")"
+}
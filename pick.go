@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+	"zgo.at/termtext"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+var errCanceled = errors.New("canceled")
+
+// Maximum number of items to show; formatting all of them on every keypress
+// is too slow, and nobody is going to scroll through 40,000 codepoints.
+const pickLimit = 500
+
+// Skin tones and genders to cycle through with ^T and ^G.
+var (
+	pickTones = []struct {
+		name string
+		mod  unidata.EmojiModifier
+	}{{"", 0}, {"light", unidata.ModLight}, {"mediumlight", unidata.ModMediumLight},
+		{"medium", unidata.ModMedium}, {"mediumdark", unidata.ModMediumDark}, {"dark", unidata.ModDark}}
+	pickGenders = []struct {
+		name string
+		mod  unidata.EmojiModifier
+	}{{"person", unidata.ModPerson}, {"man", unidata.ModMale}, {"woman", unidata.ModFemale}}
+)
+
+type pickItem struct {
+	text string             // Text to output.
+	info *unidata.Codepoint // Codepoint, or nil for emojis.
+	cols map[string]string  // Columns for the preview; for codepoints these are created on demand.
+}
+
+// picker is the state of the interactive picker.
+type picker struct {
+	emoji    bool // Emoji mode, instead of codepoints.
+	query    []rune
+	tone     int // Index in pickTones.
+	gender   int // Index in pickGenders.
+	sets     []unidata.ShortcodeSet
+	raw      bool
+	items    []pickItem
+	lines    []string // Items formatted with Format.
+	cur, top int      // Current item and first item on screen.
+	selected []int    // Multi-selected items, in order of selection.
+	more     bool     // More than pickLimit items matched.
+	err      error    // Error in the query.
+}
+
+func newPicker(args []string, tones, genders unidata.EmojiModifier, sets []unidata.ShortcodeSet, raw bool) *picker {
+	p := &picker{sets: sets, raw: raw}
+	if len(args) > 0 {
+		if m, err := match(args[0], "codepoints", "emoji"); err == nil {
+			p.emoji, args = m == "emoji", args[1:]
+		}
+	}
+	p.query = []rune(strings.Join(args, " "))
+	for i, t := range pickTones {
+		if t.mod == tones {
+			p.tone = i
+		}
+	}
+	for i, g := range pickGenders {
+		if g.mod == genders {
+			p.gender = i
+		}
+	}
+	p.update()
+	return p
+}
+
+// update the list of items after the query or mode changed.
+func (p *picker) update() {
+	p.items, p.lines, p.selected, p.cur, p.top, p.more, p.err = nil, nil, nil, 0, 0, false, nil
+	words := strings.Fields(string(p.query))
+
+	var f *Format
+	if p.emoji {
+		emojis, err := findEmojis(words, false, pickTones[p.tone].mod, pickGenders[p.gender].mod, 0, 0, p.sets)
+		if err != nil {
+			p.err = err
+			return
+		}
+		recent := recentRanks()
+		slices.SortStableFunc(emojis, func(a, b unidata.Emoji) int { return compareRecent(recent, a.String(), b.String()) })
+		if len(emojis) > pickLimit {
+			emojis, p.more = emojis[:pickLimit], true
+		}
+		f, _ = NewFormat(expandFormat(defaultEmojiCompact), printAsListCompact, emojiColumnNames...)
+		for _, e := range emojis {
+			cols := emojiColumns(e, p.sets)
+			cols["tab"] = " "
+			p.items = append(p.items, pickItem{text: e.String(), cols: cols})
+			f.Line(0, cols)
+		}
+	} else {
+		if len(words) == 0 { // Every codepoint is a bit much.
+			return
+		}
+		// Sort by codepoint, but put exact matches on the name first so that
+		// "euro sign" doesn't select EURO-CURRENCY SIGN, and then recently
+		// used characters.
+		var (
+			found  = searchCodepoints(words, false)
+			exact  = strings.ToUpper(strings.Join(words, " "))
+			recent = recentRanks()
+		)
+		slices.SortFunc(found, func(a, b unidata.Codepoint) int {
+			if ea, eb := a.Name() == exact, b.Name() == exact; ea != eb {
+				if ea {
+					return -1
+				}
+				return 1
+			}
+			if c := compareRecent(recent, string(a.Codepoint), string(b.Codepoint)); c != 0 {
+				return c
+			}
+			return int(a.Codepoint - b.Codepoint)
+		})
+		if len(found) > pickLimit {
+			found, p.more = found[:pickLimit], true
+		}
+
+		f, _ = NewFormat(expandFormat(defaultCompact), printAsListCompact, knownColumns...)
+		for _, info := range found {
+			p.items = append(p.items, pickItem{text: string(info.Codepoint), info: &info})
+			f.Line(info.Codepoint, f.toLine(info, p.raw))
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	f.Print(buf)
+	p.lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// key processes a keypress, and reports if the picker is done.
+func (p *picker) key(k string) (done bool) {
+	switch k {
+	case "enter", "ctrl-y":
+		return len(p.items) > 0
+	case "up", "ctrl-p":
+		p.cur = max(p.cur-1, 0)
+	case "down", "ctrl-n":
+		p.cur = max(min(p.cur+1, len(p.items)-1), 0)
+	case "pgup":
+		p.cur = max(p.cur-10, 0)
+	case "pgdn":
+		p.cur = max(min(p.cur+10, len(p.items)-1), 0)
+	case "tab":
+		if len(p.items) > 0 {
+			if i := slices.Index(p.selected, p.cur); i > -1 {
+				p.selected = slices.Delete(p.selected, i, i+1)
+			} else {
+				p.selected = append(p.selected, p.cur)
+			}
+			p.cur = min(p.cur+1, len(p.items)-1)
+		}
+	case "ctrl-e":
+		p.emoji = !p.emoji
+		p.update()
+	case "ctrl-t":
+		p.tone = (p.tone + 1) % len(pickTones)
+		if p.emoji {
+			p.update()
+		}
+	case "ctrl-g":
+		p.gender = (p.gender + 1) % len(pickGenders)
+		if p.emoji {
+			p.update()
+		}
+	case "backspace":
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.update()
+		}
+	case "ctrl-w":
+		q := strings.TrimRight(string(p.query), " ")
+		p.query = []rune(q[:strings.LastIndexByte(q, ' ')+1])
+		p.update()
+	case "ctrl-u":
+		p.query = nil
+		p.update()
+	default:
+		if r := []rune(k); len(r) == 1 && r[0] >= ' ' {
+			p.query = append(p.query, r[0])
+			p.update()
+		}
+	}
+	return false
+}
+
+// selection gets the text of all selected items, or the current item if
+// nothing is selected.
+func (p *picker) selection() []string {
+	if len(p.selected) == 0 {
+		return []string{p.items[p.cur].text}
+	}
+	sel := make([]string, 0, len(p.selected))
+	for _, s := range p.selected {
+		sel = append(sel, p.items[s].text)
+	}
+	return sel
+}
+
+// render the picker: the query and status on the first line, the list of
+// items, and a preview of the current item at the bottom.
+func (p *picker) render(w io.Writer, width, height int) {
+	var (
+		b        strings.Builder
+		previewH = min(8, height/3)
+		listH    = max(height-2-previewH, 1)
+		line     = func(s string) { b.WriteString(termtext.Slice(s, 0, width) + "\x1b[K\r\n") }
+	)
+	b.WriteString("\x1b[H")
+
+	status := "codepoints"
+	if p.emoji {
+		status = "emoji"
+		if t := pickTones[p.tone].name; t != "" {
+			status += ", " + t
+		}
+		status += ", " + pickGenders[p.gender].name
+	}
+	status = fmt.Sprintf("[%s] %d/%d", status, min(p.cur+1, len(p.items)), len(p.items))
+	if p.more {
+		status += "+"
+	}
+	if len(p.selected) > 0 {
+		status += fmt.Sprintf(" (%d selected)", len(p.selected))
+	}
+	prompt := "> " + string(p.query)
+	line(prompt + strings.Repeat(" ", max(width-termtext.Width(prompt)-termtext.Width(status), 1)) + status)
+
+	if p.cur < p.top {
+		p.top = p.cur
+	}
+	if p.cur >= p.top+listH {
+		p.top = p.cur - listH + 1
+	}
+	for i := p.top; i < p.top+listH; i++ {
+		switch {
+		case p.err != nil && i == 0:
+			line("  " + p.err.Error())
+		case !p.emoji && len(p.query) == 0 && i == 0:
+			line("  Type to search; ^E to switch between codepoints and emoji")
+		case i >= len(p.lines):
+			line("")
+		default:
+			mark := "  "
+			if slices.Contains(p.selected, i) {
+				mark = "* "
+			}
+			if i == p.cur {
+				b.WriteString("\x1b[7m")
+				line(mark + p.lines[i])
+				b.WriteString("\x1b[0m")
+			} else {
+				line(mark + p.lines[i])
+			}
+		}
+	}
+
+	line(strings.Repeat("─", width))
+	var preview []string
+	if len(p.items) > 0 {
+		names := knownColumns
+		if p.emoji {
+			names = emojiColumnNames
+		}
+		it := &p.items[p.cur]
+		if it.cols == nil {
+			all, _ := NewFormat(expandFormat(allFormat), printAsListCompact, knownColumns...)
+			it.cols = all.toLine(*it.info, p.raw)
+		}
+		cur, cols := "", it.cols
+		for _, n := range names {
+			v := cols[n]
+			if v == "" || n == "tab" || n == "wide_padding" || n == "cldr_full" {
+				continue
+			}
+			field := header(n) + ": " + v
+			if cur != "" && termtext.Width(cur)+2+termtext.Width(field) > width {
+				preview, cur = append(preview, cur), ""
+			}
+			if cur != "" {
+				cur += "  "
+			}
+			cur += field
+		}
+		preview = append(preview, cur)
+	}
+	for i := range previewH {
+		if i < len(preview) {
+			line(preview[i])
+		} else {
+			line("")
+		}
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprintf(&b, "\x1b[1;%dH", termtext.Width(prompt)+1)
+	w.Write([]byte(b.String()))
+}
+
+// readKey reads a keypress from the terminal.
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case '\t':
+		return "tab", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x1b:
+		// Escape sequences are sent in one go; a lone escape is the escape
+		// key.
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		c, _ := r.ReadByte()
+		if c != '[' && c != 'O' {
+			return "", nil // Alt+key
+		}
+		var seq []byte
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+		switch string(seq) {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdn", nil
+		}
+		return "", nil
+	}
+	if c < ' ' {
+		return "ctrl-" + string(rune(c+'a'-1)), nil
+	}
+	return string(c), nil
+}
+
+// runPicker reads keys from r and renders the picker on w until a selection is
+// made, which is returned. The copy return value is true if the selection
+// should be copied to the clipboard.
+func runPicker(p *picker, r io.Reader, w io.Writer, size func() (int, int)) (sel []string, copy bool, err error) {
+	w.Write([]byte("\x1b[?1049h"))       // Alternate screen.
+	defer w.Write([]byte("\x1b[?1049l")) // Restore screen.
+
+	br := bufio.NewReader(r)
+	for {
+		width, height := size()
+		p.render(w, width, height)
+
+		k, err := readKey(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, false, errCanceled
+			}
+			return nil, false, err
+		}
+		switch k {
+		case "esc", "ctrl-c", "ctrl-d":
+			return nil, false, errCanceled
+		}
+		if p.key(k) {
+			return p.selection(), k == "ctrl-y", nil
+		}
+	}
+}
+
+// pick runs the interactive picker, and prints the selection or copies it to
+// the clipboard. If copyAlways is set Enter also copies to the clipboard.
+func pick(args []string, tones, genders unidata.EmojiModifier, sets []unidata.ShortcodeSet, raw, copyAlways bool) error {
+	tty, fd, restore, err := openTTY()
+	if err != nil {
+		return err
+	}
+	sel, copy, err := runPicker(newPicker(args, tones, genders, sets, raw), tty, tty, func() (int, int) {
+		w, h, err := term.GetSize(fd)
+		if err != nil || w == 0 || h == 0 {
+			return 80, 24
+		}
+		return w, h
+	})
+	restore()
+	if err != nil {
+		return err
+	}
+
+	if err := recordHistory(sel); err != nil {
+		zli.Errorf("recording history: %s", err)
+	}
+	if copy || copyAlways {
+		return copyToClipboard(strings.Join(sel, ""))
+	}
+	fmt.Fprintln(zli.Stdout, strings.Join(sel, ""))
+	return nil
+}
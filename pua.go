@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+	"zgo.at/zstd/zmap"
+)
+
+// Names for codepoints in the Private Use Areas, from the -pua flag.
+var puaNames map[rune]string
+
+// puaDir gets the directory for the PUA name tables: $XDG_DATA_HOME/uni/pua,
+// or ~/.local/share/uni/pua if that's not set.
+func puaDir() (string, error) {
+	if d := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(d) {
+		return filepath.Join(d, "uni", "pua"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "uni", "pua"), nil
+}
+
+// loadPUA loads the PUA name tables from the -pua flag, which is a
+// comma-separated list of table names or paths to a file. Tables earlier in
+// the list take precedence if they have the same codepoint.
+func loadPUA(tables string) error {
+	dir, err := puaDir()
+	if err != nil {
+		return err
+	}
+
+	names := make(map[rune]string)
+	for _, t := range strings.Split(tables, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		path := t
+		if !strings.ContainsRune(t, filepath.Separator) && filepath.Ext(t) == "" {
+			path = filepath.Join(dir, t+".txt")
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("-pua flag: no table %q in %s; add one with \"uni pua import\"", t, dir)
+			}
+		}
+		tbl, err := readPUATable(path)
+		if err != nil {
+			return fmt.Errorf("-pua flag: %w", err)
+		}
+		for cp, n := range tbl {
+			if _, ok := names[cp]; !ok {
+				names[cp] = n
+			}
+		}
+	}
+
+	if err := unidata.SetPUANames(names); err != nil {
+		return err
+	}
+	puaNames = names
+	return nil
+}
+
+// readPUATable reads a PUA name table, which has lines in the form of:
+//
+//	U+E0A0 nf-pl-branch
+//	E0A1   nf-pl-line_number
+//
+// The codepoint can be in any format that print accepts. A JSON object with
+// codepoints as keys and names as values also works:
+//
+//	{"E0A0": "nf-pl-branch", "U+E0A1": "nf-pl-line_number"}
+func readPUATable(path string) (map[rune]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[rune]string)
+	add := func(cp, name string, lineno int) error {
+		pos := path
+		if lineno > 0 {
+			pos = fmt.Sprintf("%s:%d", path, lineno)
+		}
+		c, err := unidata.FromString(cp)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pos, err)
+		}
+		if c.Category() != unidata.CatPrivateUse {
+			return fmt.Errorf("%s: U+%04X is not in a Private Use Area", pos, c.Codepoint)
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			return fmt.Errorf("%s: no name for U+%04X", pos, c.Codepoint)
+		}
+		names[c.Codepoint] = name
+		return nil
+	}
+
+	if d := bytes.TrimSpace(data); len(d) > 0 && d[0] == '{' {
+		var tbl map[string]string
+		if err := json.Unmarshal(d, &tbl); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for cp, name := range tbl {
+			if err := add(cp, name, 0); err != nil {
+				return nil, err
+			}
+		}
+		return names, nil
+	}
+
+	var (
+		scan   = bufio.NewScanner(bytes.NewReader(data))
+		lineno int
+	)
+	for scan.Scan() {
+		lineno++
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		cp, name, ok := strings.Cut(line, " ")
+		if !ok {
+			cp, name, ok = strings.Cut(line, "\t")
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: not in the form of codepoint name: %q", path, lineno, line)
+		}
+		if err := add(cp, name, lineno); err != nil {
+			return nil, err
+		}
+	}
+	return names, scan.Err()
+}
+
+// PUA name lists that can be imported, and a function to convert them to a
+// map of codepoint → name.
+var puaImporters = map[string]func([]byte) (map[rune]string, error){
+	// glyphnames.json from Nerd Fonts:
+	// {"METADATA": {..}, "dev-git_branch": {"char": "", "code": "e725"}}
+	"nerdfonts": func(data []byte) (map[rune]string, error) {
+		var glyphs map[string]json.RawMessage
+		if err := json.Unmarshal(data, &glyphs); err != nil {
+			return nil, err
+		}
+		names := make(map[rune]string, len(glyphs))
+		for k, v := range glyphs {
+			if k == "METADATA" {
+				continue
+			}
+			var g struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(v, &g); err != nil {
+				return nil, fmt.Errorf("%q: %w", k, err)
+			}
+			addPUA(names, g.Code, "nf-"+k)
+		}
+		return names, nil
+	},
+
+	// icons.json from Font Awesome:
+	// {"code-branch": {"unicode": "f126", "label": "Code Branch", ..}}
+	"fontawesome": func(data []byte) (map[rune]string, error) {
+		var icons map[string]struct {
+			Unicode string `json:"unicode"`
+		}
+		if err := json.Unmarshal(data, &icons); err != nil {
+			return nil, err
+		}
+		names := make(map[rune]string, len(icons))
+		for k, v := range icons {
+			addPUA(names, v.Unicode, "fa-"+k)
+		}
+		return names, nil
+	},
+
+	// "name hex" lines, as used by Material Icons and others:
+	//
+	//	10k e951
+	"codepoints": func(data []byte) (map[rune]string, error) {
+		names := make(map[rune]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			if f := strings.Fields(line); len(f) == 2 {
+				addPUA(names, f[1], f[0])
+			}
+		}
+		return names, nil
+	},
+
+	// UnicodeData.txt format, as used by the ConScript Unicode Registry:
+	//
+	//	F8D0;KLINGON LETTER A;Lo;0;L;;;;;N;;;;;
+	"unicodedata": func(data []byte) (map[rune]string, error) {
+		names := make(map[rune]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			if f := strings.Split(line, ";"); len(f) > 2 && !strings.HasPrefix(f[1], "<") {
+				addPUA(names, f[0], f[1])
+			}
+		}
+		return names, nil
+	},
+}
+
+// addPUA adds the codepoint to names if it's in a Private Use Area; icon fonts
+// sometimes also include regular characters, which already have a name.
+func addPUA(names map[rune]string, hex, name string) {
+	cp, err := unidata.FromString("U+" + strings.TrimSpace(hex))
+	if err == nil && cp.Category() == unidata.CatPrivateUse && name != "" {
+		names[cp.Codepoint] = name
+	}
+}
+
+// pua lists the installed PUA name tables, or imports one.
+func pua(args []string) error {
+	sub := "list"
+	if len(args) > 0 {
+		var err error
+		sub, err = match(args[0], "import", "list")
+		if err != nil {
+			return fmt.Errorf("pua: %w", err)
+		}
+		args = args[1:]
+	}
+
+	dir, err := puaDir()
+	if err != nil {
+		return err
+	}
+
+	if sub == "list" {
+		tables, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if err != nil {
+			return err
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("pua: no tables in %s; add one with \"uni pua import\"", dir)
+		}
+		for _, t := range tables {
+			names, err := readPUATable(t)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(zli.Stdout, "%-16s %6d names  %s\n", strings.TrimSuffix(filepath.Base(t), ".txt"), len(names), t)
+		}
+		return nil
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("pua import: need a format and file, and optionally a name; formats: %s",
+			strings.Join(zmap.KeysOrdered(puaImporters), ", "))
+	}
+	imp, ok := puaImporters[args[0]]
+	if !ok {
+		return fmt.Errorf("pua import: unknown format %q; formats: %s",
+			args[0], strings.Join(zmap.KeysOrdered(puaImporters), ", "))
+	}
+	name := args[0]
+	if len(args) == 3 {
+		name = args[2]
+	}
+	if name == "" || strings.ContainsAny(name, `,/\`) {
+		return fmt.Errorf("pua import: invalid name %q", name)
+	}
+
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("pua import: %w", err)
+	}
+	names, err := imp(data)
+	if err != nil {
+		return fmt.Errorf("pua import: %s: %w", args[1], err)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("pua import: no codepoints in a Private Use Area in %s", args[1])
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Imported from %s with \"uni pua import %s\".\n", filepath.Base(args[1]), args[0])
+	for _, cp := range zmap.KeysOrdered(names) {
+		fmt.Fprintf(&b, "U+%04X %s\n", cp, names[cp])
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name+".txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(zli.Stdout, "imported %d names to %s; use with \"-pua %s\"\n", len(names), path, name)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zstd/zstring"
+)
+
+// query selects codepoints by codepoint, range, category, block, script, or
+// property; this is used by print, identify -filter, and termprobe.
+type query struct {
+	desc   string    // Description for the "Showing .." header; empty for codepoints.
+	ranges [][2]rune // Codepoint ranges, if this isn't a category.
+	cat    unidata.Category
+	isCat  bool
+}
+
+// parseQuery parses a query; q should be lower-case.
+func parseQuery(q string) (query, error) {
+	var (
+		catOk, blOk, pOk, scOk bool
+		cat                    unidata.Category
+		bl                     unidata.Block
+		p                      unidata.Property
+		sc                     unidata.Script
+	)
+	switch {
+	case zstring.HasPrefixes(q, "block:", "b:"):
+		q = q[strings.IndexByte(q, ':')+1:]
+		bl, blOk = unidata.FindBlock(q)
+		if !blOk {
+			return query{}, fmt.Errorf("unknown or ambiguous block: %q", q)
+		}
+	case zstring.HasPrefixes(q, "script:", "s:"):
+		q = q[strings.IndexByte(q, ':')+1:]
+		sc, scOk = unidata.FindScript(q)
+		if !scOk {
+			return query{}, fmt.Errorf("unknown or ambiguous script: %q", q)
+		}
+	case zstring.HasPrefixes(q, "category:", "cat:", "c:"):
+		q = q[strings.IndexByte(q, ':')+1:]
+		cat, catOk = unidata.FindCategory(q)
+		if !catOk {
+			return query{}, fmt.Errorf("unknown or ambiguous category: %q", q)
+		}
+	case zstring.HasPrefixes(q, "property:", "prop:", "p:"):
+		q = q[strings.IndexByte(q, ':')+1:]
+		p, pOk = unidata.FindProperty(q)
+		if !pOk {
+			return query{}, fmt.Errorf("unknown or ambiguous property: %q", q)
+		}
+	default:
+		cat, catOk = unidata.FindCategory(q)
+		bl, blOk = unidata.FindBlock(q)
+		p, pOk = unidata.FindProperty(q)
+		if nbools(catOk, blOk, pOk) > 1 {
+			opt := make([]string, 0, 3)
+			if catOk {
+				opt = append(opt, fmt.Sprintf("Category(%q)", cat))
+			}
+			if blOk {
+				opt = append(opt, fmt.Sprintf("Block(%q)", bl))
+			}
+			if pOk {
+				opt = append(opt, fmt.Sprintf("Property(%q)", p))
+			}
+			return query{}, fmt.Errorf("%q matched multiple options:\n\t%s\nPrefix with 'block:', 'category:', or 'property:'",
+				q, strings.Join(opt, ", "))
+		}
+	}
+
+	switch {
+	case catOk:
+		cc := unidata.Categories[cat]
+		return query{desc: fmt.Sprintf("category %s (%s)", cc.ShortName, cc.Name), cat: cat, isCat: true}, nil
+	case scOk:
+		return query{desc: "script " + unidata.Scripts[sc].Name, ranges: unidata.Scripts[sc].Ranges}, nil
+	case blOk:
+		return query{desc: fmt.Sprintf("block %s", bl), ranges: [][2]rune{unidata.Blocks[bl].Range}}, nil
+	case pOk:
+		return query{desc: fmt.Sprintf("property %s", p), ranges: unidata.Properties[p].Ranges}, nil
+	}
+
+	// U2042, U+2042, U+2042..U+2050, 2042..2050, 2042-2050, 0x2041, etc.
+	var s []string
+	switch {
+	case strings.Contains(q, ".."):
+		s = strings.SplitN(q, "..", 2)
+	case strings.Contains(q, "-"):
+		s = strings.SplitN(q, "-", 2)
+	default:
+		s = []string{q, q}
+	}
+	s[0], s[1] = strings.TrimSpace(s[0]), strings.TrimSpace(s[1])
+
+	start, err := unidata.FromString(s[0])
+	if err != nil {
+		return query{}, fmt.Errorf("invalid codepoint: %s", errors.Unwrap(err))
+	}
+	end, err := unidata.FromString(s[1])
+	if err != nil {
+		return query{}, fmt.Errorf("invalid codepoint: %s", errors.Unwrap(err))
+	}
+	if start.Codepoint > end.Codepoint {
+		return query{}, fmt.Errorf("end of range %q is lower than start %q", s[1], s[0])
+	}
+	return query{ranges: [][2]rune{{start.Codepoint, end.Codepoint}}}, nil
+}
+
+// match reports if the codepoint matches the query.
+func (q query) match(info unidata.Codepoint) bool {
+	if q.isCat {
+		return info.Category() == q.cat || slices.Contains(unidata.Categories[q.cat].Include, info.Category())
+	}
+	for _, r := range q.ranges {
+		if info.Codepoint >= r[0] && info.Codepoint <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// each calls fn for every codepoint that matches the query.
+//
+// Ranges from a category, block, script, or property only include codepoints
+// listed in unidata.Codepoints, whereas ranges of codepoints include every
+// codepoint.
+func (q query) each(fn func(unidata.Codepoint)) {
+	if q.isCat {
+		for _, info := range unidata.Codepoints {
+			if q.match(info) {
+				info, _ = unidata.Find(info.Codepoint)
+				fn(info)
+			}
+		}
+		return
+	}
+	for _, r := range q.ranges {
+		for cp := r[0]; cp <= r[1]; cp++ {
+			if _, ok := unidata.Codepoints[cp]; ok || q.desc == "" {
+				info, _ := unidata.Find(cp)
+				fn(info)
+			}
+		}
+	}
+}
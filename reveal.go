@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// hiddenText is a payload hidden with invisible codepoints.
+type hiddenText struct {
+	Line       int    `json:"line"`
+	Col        int    `json:"col"`
+	Offset     int    `json:"offset"`
+	Kind       string `json:"kind"`
+	Scheme     string `json:"scheme,omitempty"`
+	Codepoints int    `json:"codepoints"`
+	Payload    string `json:"payload"`
+	Hex        string `json:"hex"`
+}
+
+func inBlock(b unidata.Block, r rune) bool {
+	return r >= unidata.Blocks[b].Range[0] && r <= unidata.Blocks[b].Range[1]
+}
+
+func isVariationSelector(r rune) bool {
+	return inBlock(unidata.BlockVariationSelectors, r) || inBlock(unidata.BlockVariationSelectorsSupplement, r)
+}
+
+// Zero-width characters used for binary encodings.
+func isZeroWidth(r rune) bool {
+	return (r >= 0x200b && r <= 0x200d) || (r >= 0x2060 && r <= 0x2064) || r == 0xfeff
+}
+
+// findHidden finds payloads hidden in the text:
+//
+//   - Tag characters, which map to ASCII by subtracting 0xE0000. Tags that are
+//     part of an emoji flag are skipped.
+//
+//   - Runs of two or more variation selectors, which encode bytes 0-15 as
+//     U+FE00..U+FE0F and 16-255 as U+E0100..U+E01EF.
+//
+//   - Runs of eight or more zero-width characters, which encode bits; see
+//     decodeZeroWidth().
+func findHidden(text string) []hiddenText {
+	var (
+		runes []rune
+		sizes []int
+	)
+	for len(text) > 0 {
+		r, s := utf8.DecodeRuneInString(text)
+		runes, sizes = append(runes, r), append(sizes, s)
+		text = text[s:]
+	}
+
+	var (
+		found []hiddenText
+		pos   = newPosition()
+		skip  = func(n int, i int) int {
+			for j := i; j < i+n; j++ {
+				pos.advance(runes[j], sizes[j])
+			}
+			return i + n
+		}
+		run = func(i int, in func(rune) bool) []rune {
+			j := i
+			for j < len(runes) && in(runes[j]) {
+				j++
+			}
+			return runes[i:j]
+		}
+	)
+	for i := 0; i < len(runes); {
+		if _, n := unidata.FlagRegion(runes[i:]); n > 0 {
+			i = skip(n, i)
+			continue
+		}
+
+		h := hiddenText{Line: pos.line, Col: pos.col, Offset: pos.offset}
+		var payload []byte
+		switch r := runes[i]; {
+		case inBlock(unidata.BlockTags, r):
+			tags := run(i, func(r rune) bool { return inBlock(unidata.BlockTags, r) })
+			h.Kind, h.Codepoints = "tags", len(tags)
+			for _, t := range tags {
+				if t >= 0xe0020 && t <= 0xe007e {
+					payload = append(payload, byte(t-0xe0000))
+				}
+			}
+		case isVariationSelector(r):
+			vs := run(i, isVariationSelector)
+			if len(vs) < 2 { // Single variation selectors are normal.
+				i = skip(1, i)
+				continue
+			}
+			h.Kind, h.Codepoints = "variation selectors", len(vs)
+			for _, v := range vs {
+				if v < 0xe0100 {
+					payload = append(payload, byte(v-0xfe00))
+				} else {
+					payload = append(payload, byte(v-0xe0100+16))
+				}
+			}
+		case isZeroWidth(r):
+			zw := run(i, isZeroWidth)
+			if len(zw) < 8 {
+				i = skip(len(zw), i)
+				continue
+			}
+			h.Kind, h.Codepoints = "zero-width binary", len(zw)
+			payload, h.Scheme = decodeZeroWidth(zw)
+		default:
+			i = skip(1, i)
+			continue
+		}
+
+		h.Payload, h.Hex = string(payload), fmt.Sprintf("% x", payload)
+		found = append(found, h)
+		i = skip(h.Codepoints, i)
+	}
+	return found
+}
+
+// decodeZeroWidth decodes zero-width characters used as binary digits.
+//
+// With two different characters one is 0 and the other is 1, and every 8 bits
+// is a byte. With three different characters one is a separator and the bits
+// between them are a codepoint. Every option is tried, and the one with the
+// most printable text is used. This returns nil if nothing decodes to text.
+func decodeZeroWidth(zw []rune) ([]byte, string) {
+	var distinct []rune
+	for _, r := range zw {
+		if !slices.Contains(distinct, r) {
+			distinct = append(distinct, r)
+		}
+	}
+
+	var (
+		best      []byte
+		bestScore float64
+		scheme    string
+		try       = func(zero, one, sep rune) {
+			var (
+				out  []byte
+				bits uint32
+				n    int
+			)
+			flush := func() bool {
+				if n == 0 {
+					return true
+				}
+				if sep == 0 {
+					out = append(out, byte(bits))
+				} else {
+					if bits > unicode.MaxRune {
+						return false
+					}
+					out = utf8.AppendRune(out, rune(bits))
+				}
+				bits, n = 0, 0
+				return true
+			}
+			for _, r := range zw {
+				switch r {
+				case sep:
+					if !flush() {
+						return
+					}
+					continue
+				case zero:
+					bits <<= 1
+				case one:
+					bits = bits<<1 | 1
+				}
+				n++
+				if sep == 0 && n == 8 {
+					flush()
+				}
+			}
+			if n > 0 && (sep == 0 || !flush()) { // Leftover bits.
+				return
+			}
+
+			if score := printable(out); score > bestScore {
+				best, bestScore = out, score
+				scheme = fmt.Sprintf("U+%04X=0 U+%04X=1", zero, one)
+				if sep != 0 {
+					scheme += fmt.Sprintf(" U+%04X=separator", sep)
+				}
+			}
+		}
+	)
+	switch len(distinct) {
+	case 2:
+		try(distinct[0], distinct[1], 0)
+		try(distinct[1], distinct[0], 0)
+	case 3:
+		for i, sep := range distinct {
+			other := slices.Delete(slices.Clone(distinct), i, i+1)
+			try(other[0], other[1], sep)
+			try(other[1], other[0], sep)
+		}
+	}
+	if bestScore < .9 {
+		return nil, ""
+	}
+	return best, scheme
+}
+
+// printable gets the fraction of printable characters in b, or 0 if it's not
+// valid UTF-8.
+func printable(b []byte) float64 {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return 0
+	}
+	var p, n int
+	for _, r := range string(b) {
+		n++
+		if unicode.IsPrint(r) || r == '\n' || r == '\t' {
+			p++
+		}
+	}
+	return float64(p) / float64(n)
+}
+
+// reveal prints all payloads hidden in the args, or the file in path; stdin is
+// read if path is "-".
+func reveal(args []string, path string, as printAs) error {
+	var (
+		text   = strings.Join(args, " ")
+		prefix string
+	)
+	if path != "" {
+		var (
+			data []byte
+			err  error
+		)
+		if path == "-" {
+			data, err = io.ReadAll(zli.Stdin)
+			path = "(standard input)"
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		text, prefix = string(data), path+":"
+	}
+
+	found := findHidden(text)
+	if len(found) == 0 {
+		return errNoMatches
+	}
+
+	if as == printAsJSON || as == printAsJSONCompact {
+		j, err := json.MarshalIndent(found, "", "\t")
+		if as == printAsJSONCompact {
+			j, err = json.Marshal(found)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(zli.Stdout, string(j))
+		return nil
+	}
+
+	for _, h := range found {
+		kind := h.Kind
+		if h.Scheme != "" {
+			kind += " (" + h.Scheme + ")"
+		}
+		payload := "could not decode"
+		if utf8.ValidString(h.Payload) && h.Payload != "" {
+			payload = strconv.Quote(h.Payload)
+		} else if h.Payload != "" {
+			payload = h.Hex
+		}
+		fmt.Fprintf(zli.Stdout, "%s%d:%d: %s, %d codepoints: %s\n", prefix, h.Line, h.Col, kind, h.Codepoints, payload)
+	}
+	return nil
+}
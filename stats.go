@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+	"zgo.at/zstd/zstring"
+)
+
+// Groups for stats -by; "all" also includes codepoint.
+var (
+	statsGroups        = []string{"script", "block", "category", "plane", "unicode", "codepoint"}
+	defaultStatsGroups = "script,block,category,plane,unicode"
+)
+
+func parseStatsGroups(by string) ([]string, error) {
+	if by == "" {
+		by = defaultStatsGroups
+	}
+	if by == "all" {
+		return statsGroups, nil
+	}
+	var groups []string
+	for _, b := range strings.Split(by, ",") {
+		g, err := match(strings.TrimSpace(b), statsGroups...)
+		if err != nil {
+			return nil, fmt.Errorf("-by: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// countRunes counts all codepoints in the files, or stdin if there are no
+// files or the path is "-".
+func countRunes(paths []string, enc encoding.Encoding) (map[rune]int, int, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var (
+		counts = make(map[rune]int)
+		total  int
+	)
+	for _, p := range paths {
+		var (
+			data []byte
+			err  error
+		)
+		if p == "-" {
+			data, err = io.ReadAll(zli.Stdin)
+		} else {
+			data, err = os.ReadFile(p)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		data, err = decodeInput(data, enc)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", p, err)
+		}
+
+		for _, r := range string(data) {
+			counts[r]++
+			total++
+		}
+	}
+	return counts, total, nil
+}
+
+// stats prints the number of codepoints in the input grouped by script, block,
+// etc. The format is used for every group.
+func stats(paths []string, enc encoding.Encoding, by []string, format string, formatSet, raw bool, as printAs) error {
+	if as == printAsTable || as == printAsTableCompact {
+		zli.Fatalf("can't use -as table with the stats command")
+	}
+
+	// Make the formats first, so that a -format with columns that only work
+	// for the codepoint group is an error before reading the input.
+	formats := make([]*Format, len(by))
+	for i, g := range by {
+		var err error
+		formats[i], err = statsFormat(g, format, formatSet, as)
+		if err != nil {
+			return err
+		}
+	}
+
+	counts, total, err := countRunes(paths, enc)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return errNoMatches
+	}
+
+	pct := func(n int) string {
+		if as == printAsJSON || as == printAsJSONCompact {
+			return strconv.FormatFloat(float64(n)/float64(total)*100, 'f', 2, 64)
+		}
+		return strconv.FormatFloat(float64(n)/float64(total)*100, 'f', 1, 64) + "%"
+	}
+
+	infos := make(map[rune]unidata.Codepoint, len(counts))
+	for r := range counts {
+		infos[r], _ = unidata.Find(r)
+	}
+
+	// With several groups the JSON is an object with the group as key, rather
+	// than an array for every group.
+	var (
+		jsonObj = (as == printAsJSON || as == printAsJSONCompact) && len(by) > 1
+		obj     = new(bytes.Buffer)
+	)
+	printGroup := func(i int, g string, f *Format) {
+		if !jsonObj {
+			f.Print(zli.Stdout)
+			return
+		}
+		buf := new(bytes.Buffer)
+		f.Print(buf)
+		k := `"` + g + `"`
+		if isTerm {
+			k = `"` + hlKey + g + reset + `"`
+		}
+		fmt.Fprintf(obj, "%s%s: %s", map[bool]string{true: "{", false: ",\n"}[i == 0], k, bytes.TrimSpace(buf.Bytes()))
+	}
+
+	for i, g := range by {
+		if (as == printAsList || as == printAsListCompact) && len(by) > 1 {
+			if i > 0 {
+				fmt.Fprintln(zli.Stdout)
+			}
+			fmt.Fprintf(zli.Stdout, "%s:\n", zstring.UpperFirst(g))
+		}
+
+		f := formats[i]
+		if g == "codepoint" {
+			order := make([]rune, 0, len(counts))
+			for r := range counts {
+				order = append(order, r)
+			}
+			slices.SortFunc(order, func(a, b rune) int {
+				return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+			})
+			for _, r := range order {
+				cols := f.toLine(infos[r], raw)
+				cols["count"], cols["percent"] = strconv.Itoa(counts[r]), pct(counts[r])
+				f.Line(r, cols)
+			}
+			printGroup(i, g, f)
+			continue
+		}
+
+		grouped := make(map[string]int)
+		for r, n := range counts {
+			var name string
+			switch info := infos[r]; g {
+			case "script":
+				name = info.Script().String()
+			case "block":
+				name = info.Block().String()
+			case "category":
+				name = info.Category().String()
+			case "plane":
+				name = info.Plane().String()
+			case "unicode":
+				name = info.Unicode().String()
+			}
+			if name == "" {
+				name = "Unknown"
+			}
+			grouped[name] += n
+		}
+
+		order := make([]string, 0, len(grouped))
+		for name := range grouped {
+			order = append(order, name)
+		}
+		slices.SortFunc(order, func(a, b string) int {
+			return cmp.Or(cmp.Compare(grouped[b], grouped[a]), cmp.Compare(a, b))
+		})
+		for _, name := range order {
+			f.Line(0, map[string]string{
+				"count":   strconv.Itoa(grouped[name]),
+				"percent": pct(grouped[name]),
+				"name":    name,
+			})
+		}
+		printGroup(i, g, f)
+	}
+	if jsonObj {
+		fmt.Fprintf(zli.Stdout, "%s}\n", obj)
+	}
+	return nil
+}
+
+// statsFormat gets the format for the group; the codepoint group can use all
+// the columns identify can, the other groups only count, percent, and name.
+func statsFormat(group, format string, formatSet bool, as printAs) (*Format, error) {
+	if group == "codepoint" {
+		if !formatSet || format == allFormat {
+			format = "%(count r:auto)  %(percent r:auto)  " + map[bool]string{true: allFormat, false: defaultCompact}[formatSet]
+		}
+		return NewFormat(format, as, append([]string{"count", "percent"}, knownColumns...)...)
+	}
+
+	if !formatSet || format == allFormat {
+		format = "%(count r:auto)  %(percent r:auto)  %(name l:auto)"
+	}
+	f, err := NewFormat(format, as, "count", "percent", "name")
+	if err != nil {
+		return nil, fmt.Errorf("%w; only %%(count), %%(percent), and %%(name) work with -by %s", err, group)
+	}
+	return f, nil
+}
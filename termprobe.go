@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// How long to wait for the terminal to report the cursor position.
+var probeTimeout = 2 * time.Second
+
+type probeItem struct {
+	text string
+	info *unidata.Codepoint // nil for emojis.
+	name string
+}
+
+// probeItems gets the text to probe: "emoji" for all emojis or "emoji:group"
+// for emojis in a group, or codepoints selected in the same way as print.
+func probeItems(args []string) ([]probeItem, error) {
+	var (
+		items   []probeItem
+		filters []query
+	)
+	for _, a := range strings.Split(strings.Join(args, ","), ",") {
+		a = strings.TrimSpace(strings.ToLower(a))
+		if a == "" {
+			continue
+		}
+		if a == "emoji" || strings.HasPrefix(a, "emoji:") {
+			group := strings.TrimPrefix(strings.TrimPrefix(a, "emoji"), ":")
+			found := false
+			for _, e := range unidata.Emojis {
+				if e.Status() != unidata.StatusFullyQualified {
+					continue
+				}
+				if group != "" && !strings.HasPrefix(strings.ToLower(e.Group().String()), group) {
+					continue
+				}
+				items = append(items, probeItem{text: e.String(), name: e.Name})
+				found = true
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown emoji group: %q", group)
+			}
+			continue
+		}
+
+		q, err := parseQuery(a)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, q)
+	}
+
+	if len(filters) > 0 {
+		for cp := rune(0); cp <= unicode.MaxRune; cp++ {
+			info, ok := unidata.Find(cp)
+			if !ok {
+				continue
+			}
+			// Control characters would mess up the terminal, and surrogates
+			// can't be printed.
+			if info.Category() == unidata.CatCc || info.Category() == unidata.CatCs {
+				continue
+			}
+			if slices.ContainsFunc(filters, func(q query) bool { return q.match(info) }) {
+				items = append(items, probeItem{text: string(cp), info: &info, name: info.Name()})
+			}
+		}
+	}
+	return items, nil
+}
+
+// probeWidths prints every text at the start of the line, and uses a cursor
+// position report (DSR, "ESC[6n") to get the number of cells it took.
+//
+// The texts are written in batches to avoid waiting for the terminal on every
+// text; the terminal answers the requests in order.
+func probeWidths(tty *os.File, texts []string) ([]int, error) {
+	const batch = 64
+	var (
+		r      = bufio.NewReader(tty)
+		widths = make([]int, 0, len(texts))
+	)
+	for i := 0; i < len(texts); i += batch {
+		chunk := texts[i:min(i+batch, len(texts))]
+		var b strings.Builder
+		for _, t := range chunk {
+			b.WriteString("\r" + t + "\x1b[6n\r\x1b[K")
+		}
+		_, err := tty.WriteString(b.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for range chunk {
+			tty.SetReadDeadline(time.Now().Add(probeTimeout))
+			col, err := readCursorCol(r)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					return nil, errors.New("no cursor position report from the terminal; does it support DSR?")
+				}
+				return nil, err
+			}
+			widths = append(widths, col-1)
+		}
+	}
+	return widths, nil
+}
+
+// readCursorCol reads a cursor position report ("ESC[row;colR") and returns the
+// column. Anything before it is skipped.
+func readCursorCol(r *bufio.Reader) (int, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != 0x1b {
+			continue
+		}
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		} else if b != '[' {
+			continue
+		}
+
+		rep, err := r.ReadString('R')
+		if err != nil {
+			return 0, err
+		}
+		_, col, ok := strings.Cut(strings.TrimSuffix(rep, "R"), ";")
+		if !ok {
+			continue
+		}
+		if c, err := strconv.Atoi(col); err == nil {
+			return c, nil
+		}
+	}
+}
+
+// termprobe measures how many cells characters take up in the terminal, and
+// compares it with the widths that uni calculates.
+func termprobe(args []string, format string, formatSet, diff, raw bool, as printAs) error {
+	if as == printAsTable || as == printAsTableCompact {
+		zli.Fatalf("can't use -as table with the termprobe command")
+	}
+	if len(args) == 0 {
+		return errors.New("need a query: codepoints, ranges, categories, blocks, scripts, properties, or emoji")
+	}
+
+	items, err := probeItems(args)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errNoMatches
+	}
+
+	termName := os.Getenv("TERM_PROGRAM")
+	if termName == "" {
+		termName = os.Getenv("TERM")
+	} else if v := os.Getenv("TERM_PROGRAM_VERSION"); v != "" {
+		termName += " " + v
+	}
+
+	cols := []string{"char", "cpoints", "name", "terminal", "term"}
+	if !formatSet {
+		format = "%(char q l:auto)  %(terminal r:auto)  "
+		for _, e := range widthEngines {
+			format += "%(" + e.name + " r:auto)  "
+		}
+		format += "%(cpoints l:auto)  %(name)"
+		if as == printAsJSON || as == printAsJSONCompact {
+			format += " %(term)"
+		}
+	}
+	for _, e := range widthEngines {
+		cols = append(cols, e.name)
+	}
+	f, err := NewFormat(format, as, cols...)
+	if err != nil {
+		return err
+	}
+
+	texts := make([]string, 0, len(items))
+	for _, it := range items {
+		texts = append(texts, it.text)
+	}
+	tty, _, restore, err := openTTY()
+	if err != nil {
+		return err
+	}
+	widths, err := probeWidths(tty, texts)
+	restore()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, it := range items {
+		line := map[string]string{
+			"char":     it.text,
+			"name":     it.name,
+			"terminal": strconv.Itoa(widths[i]),
+			"term":     termName,
+		}
+		if it.info != nil && !raw {
+			line["char"] = it.info.Display()
+		}
+		same := true
+		for _, e := range widthEngines {
+			w := strconv.Itoa(e.width(it.text))
+			line[e.name] = w
+			if w != line["terminal"] {
+				same = false
+			}
+		}
+		if diff && same {
+			continue
+		}
+
+		cp := make([]string, 0, 1)
+		for _, r := range it.text {
+			cp = append(cp, fmt.Sprintf("U+%04X", r))
+		}
+		line["cpoints"] = strings.Join(cp, " ")
+		f.Line(0, line)
+		found = true
+	}
+	if !found {
+		return errNoMatches
+	}
+	f.Print(zli.Stdout)
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"zgo.at/runewidth"
+	"zgo.at/zli"
+)
+
+// fakeTerm opens a pty and runs a fake terminal on it, which answers cursor
+// position requests with the width of the text since the last \r. Ambiguous
+// characters are wide, like in a CJK terminal.
+//
+// It returns the path to the pty for ttyPath.
+func fakeTerm(t *testing.T, respond bool) string {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("can't open pty: %s", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	err = unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		var (
+			r    = bufio.NewReader(master)
+			cond = &runewidth.Condition{EastAsianWidth: true}
+			col  int
+		)
+		for {
+			c, _, err := r.ReadRune()
+			if err != nil {
+				return
+			}
+			switch c {
+			case '\r':
+				col = 0
+			case 0x1b:
+				r.ReadByte() // [
+				var seq []byte
+				for {
+					b, err := r.ReadByte()
+					if err != nil {
+						return
+					}
+					seq = append(seq, b)
+					if b >= 0x40 && b <= 0x7e {
+						break
+					}
+				}
+				if string(seq) == "6n" && respond {
+					fmt.Fprintf(master, "\x1b[1;%dR", col+1)
+				}
+			default:
+				col += cond.RuneWidth(c)
+			}
+		}
+	}()
+	return fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestTermprobe(t *testing.T) {
+	tests := []struct {
+		args    []string
+		respond bool
+		want    string
+	}{
+		{[]string{"-c", "U+41,U+B1,U+65E5"}, true, "" +
+			"'A'   1  1  1  1  U+0041  LATIN CAPITAL LETTER A\n" +
+			"'±'   2  1  1  1  U+00B1  PLUS-MINUS SIGN\n" +
+			"'日'  2  2  2  2  U+65E5  <CJK Ideograph>\n"},
+		{[]string{"-diff", "U+41,U+B1,U+65E5"}, true, "" +
+			" Char    Terminal  Runewidth  Uniseg  EAW  CPoints  Name\n" +
+			"'±'            2          1       1    1  U+00B1   PLUS-MINUS SIGN\n"},
+		{[]string{"-diff", "-ambiguous", "wide", "U+41..U+42", "U+B1"}, true,
+			"uni: no matches\n"},
+		{[]string{"-ambiguous", "wide", "-as", "json", "-c", "-f", "%(char) %(terminal) %(eaw)", "U+B1"}, true,
+			`[{"char":"±","eaw":"2","terminal":"2"}]` + "\n"},
+		{[]string{"-f", "%(terminal) %(runewidth) %(uniseg) %(name)", "-c", "emoji:flags", "-diff"}, true, "" +
+			"4 2 2 white flag\n" +
+			"6 4 2 rainbow flag\n" +
+			"8 4 2 transgender flag\n" +
+			"6 4 2 pirate flag\n" +
+			"8 8 2 flag: England\n" +
+			"8 8 2 flag: Scotland\n" +
+			"8 8 2 flag: Wales\n"},
+		{[]string{"emoji:nope"}, true, `uni: unknown emoji group: "nope"` + "\n"},
+		{[]string{"U+41"}, false, "uni: no cursor position report from the terminal; does it support DSR?\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Setenv("UNI_AMBIGUOUS", "narrow")
+			ttyPath = fakeTerm(t, tt.respond)
+			probeTimeout = 100 * time.Millisecond
+			defer func() { ttyPath, probeTimeout = "/dev/tty", 2*time.Second }()
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni", "termprobe"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Terminal for termprobe and pick; this is a variable so tests can use a pty.
+var ttyPath = "/dev/tty"
+
+// openTTY opens the terminal in raw mode. The returned function restores the
+// terminal and closes the file.
+func openTTY() (*os.File, int, func(), error) {
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("opening terminal: %w", err)
+	}
+
+	// Don't use Fd(), as that sets the file to blocking mode and read
+	// deadlines no longer work.
+	var fd int
+	rc, err := tty.SyscallConn()
+	if err != nil {
+		tty.Close()
+		return nil, 0, nil, fmt.Errorf("opening terminal: %w", err)
+	}
+	rc.Control(func(f uintptr) { fd = int(f) })
+
+	st, err := term.MakeRaw(fd)
+	if err != nil {
+		tty.Close()
+		return nil, 0, nil, fmt.Errorf("opening terminal: %w", err)
+	}
+	return tty, fd, func() { term.Restore(fd, st); tty.Close() }, nil
+}
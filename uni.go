@@ -4,8 +4,11 @@
 package main
 
 import (
+	"cmp"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"slices"
@@ -14,6 +17,8 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/rivo/uniseg"
+	"zgo.at/runewidth"
 	"zgo.at/uni/v2/unidata"
 	"zgo.at/zli"
 	"zgo.at/zstd/zmap"
@@ -44,6 +49,21 @@ Commands:
     search         Search description for any of the words.
     print          Print characters by codepoint, category, or block.
     emoji          Search emojis.
+    emojify        Replace emoji shortcodes such as :tada: with the emoji.
+    demojize       Replace emojis with shortcodes.
+    unescape       Decode escapes such as \u2713 or &#x2713;.
+    escape         Escape text for a string literal.
+    mojibake       Find and repair mojibake such as "cafÃ©".
+    hexdump        Hex dump that groups bytes into UTF-8 sequences.
+    audit          Find suspicious characters in source files.
+    reveal         Decode text hidden with invisible characters.
+    stats          Count characters by script, block, category, etc.
+    width          Compare the display width of text.
+    termprobe      Measure the width of characters in the terminal.
+    pick           Interactively search and pick characters or emojis.
+    recent         Show recently used characters and emojis.
+    fav            Add, remove, or list favourite characters and emojis.
+    pua            Import or list name tables for the Private Use Areas.
 
 Use "%(prog) help" or "%(prog) -h" for a more detailed help.
 `)
@@ -67,6 +87,7 @@ Flags:
                              include all columns.
                      table   Output as table; instead of listing the codepoints
                              on every line. This ignores the -format flag.
+                     sarif   SARIF for code scanning tools; only for audit.
 
     -c, -compact   More compact output; don't print header, "no matches", etc.
                    For json output it uses minified output, and for table it
@@ -80,6 +101,62 @@ Flags:
     -o, -or        Use "or" when searching: match if at least one parameter
                    matches, instead of only when all parameters match.
 
+    -sc, -shortcodes
+                   Shortcode sets to use for the emoji, emojify, and demojize
+                   commands, as a comma-separated list in order of
+                   preference: github, slack, discord, or cldr. The default
+                   is to use all of them, in that order.
+
+    -unescape      Decode escapes in the input for identify; see the unescape
+                   command.
+
+    -encoding      Encoding of the input: utf-8 (default), latin1, cp1252,
+                   cp437, shift_jis, gb18030, koi8-r, utf-16, utf-16le,
+                   utf-16be, utf-32, utf-32le, or utf-32be.
+
+                   UTF-16 and UTF-32 with a byte order mark are detected
+                   automatically for stdin; utf-16 and utf-32 use the byte
+                   order mark if there is one, or big-endian if there isn't.
+
+    -file          Read the input for identify, hexdump, or reveal from a file,
+                   or stdin if this is "-". For identify the file is read in
+                   chunks and printed as it's read, so large files and files
+                   without newlines work. Every line is prefixed with
+                   "file:line:col:", which most editors can jump to.
+
+    -nonascii      Only print non-ASCII characters for identify and hexdump.
+
+    -filter        Only print characters matching the query for identify; this
+                   is a comma-separated list of codepoints, ranges, categories,
+                   blocks, scripts, or properties, in the same format as print.
+                   For example: -filter 'cat:Cf,p:White_Space,U+2000..U+206F'
+
+    -ambiguous     Width of characters with an ambiguous East Asian Width,
+                   such as "±" or "①": narrow (1 cell) or wide (2 cells), as
+                   in most CJK terminals. The default is $UNI_AMBIGUOUS, or
+                   detected from the locale if that's not set. This is used
+                   for %(cells), alignment, and tables.
+
+    -copy          Copy the characters or emojis to the clipboard, in addition
+                   to printing them. For commands that don't print characters
+                   (such as emojify or unescape) the output is copied. This
+                   uses the OSC 52 terminal escape, which works over SSH and
+                   in tmux, and wl-copy, xclip, or xsel if they're available.
+                   For pick this copies the selection instead of printing it.
+                   The copied characters and emojis are remembered for the
+                   recent command.
+
+    -copy-format   Copy this column, or a format string like -format, instead
+                   of the character; every line is copied on its own line.
+                   For example "-copy-format cpoint". This implies -copy.
+
+    -pua           Names for codepoints in the Private Use Areas, which are
+                   otherwise all "<Private Use>". This is a comma-separated
+                   list of tables added with "uni pua import", or paths to a
+                   table; see the pua command. For example "-pua nerdfonts"
+                   shows the names of Nerd Fonts icons in identify, search,
+                   and print, and search can find them.
+
     -q, -quiet     Backwards-compatible alias for -c/-compact.
     -j, -json      Backwards-compatible alias for -as json
 
@@ -110,6 +187,12 @@ Commands:
                                       2042..2050
                                       '0o20102 - 0d8272'
 
+                       Escapes     Any escape that the unescape command
+                                   understands, such as \u2713, \U0001F600,
+                                   &#x2713;, or %E2%9C%93. Every codepoint in
+                                   the decoded text is printed. URL escapes
+                                   must be UTF-8; %2713 is a codepoint.
+
                        UTF-8       UTF-8 byte sequence, optionally separated by
                                    any combination of '0x', '-', '_', or spaces.
                                    For example these are all U+20AC (€):
@@ -151,9 +234,25 @@ Commands:
 
                      You can use <prefix>:query to search in specific fields:
 
-                         group: g:    Group and subgroup
-                         name:  n:    Emoji name
-                         cldr:  c:    CLDR data
+                         group:   g:    Group and subgroup
+                         name:    n:    Emoji name
+                         cldr:    c:    CLDR data
+                         version: v:    Emoji version; can be prefixed with
+                                        >=, <=, >, <, or = (the default):
+                                        v:>=14, v:<=13.1
+                         status:        Qualification status: fully-qualified,
+                                        minimally-qualified, unqualified, or
+                                        component. Can be abbreviated.
+                         shortcode: sc: Exact shortcode, with or without the
+                                        colons: sc:tada, sc::+1:
+                         flag:          Flag for an ISO 3166 region code, or
+                                        the subdivisions gb-eng, gb-sct, and
+                                        gb-wls: flag:NL, flag:gb-sct. Region
+                                        flags are always added, even if they're
+                                        not in the emoji list.
+
+                     Minimally-qualified and unqualified emojis are only shown
+                     if the status: prefix is used.
 
                      The query parameters are AND'd together, so this:
 
@@ -163,9 +262,12 @@ Commands:
                      in the name. Use the -or flag to change this to "cat-face
                      group OR smiling in the name".
 
+                     ASCII emoticons such as :) or <3 find the emoji they
+                     represent.
+
                      Use "all" to show all emojis.
 
-                     Modifier flags, both accept a comma-separated list:
+                     Modifier flags, all accept a comma-separated list:
 
                          -g, -gender   Set the gender:
                                            p, person, people
@@ -180,13 +282,272 @@ Commands:
                                            md, mediumdark, medium-dark
                                            d,  dark
 
+                         -hair         Set the hair style:
+                                           n, none
+                                           r, red
+                                           c, curly
+                                           w, white
+                                           b, bald
+
+                         -dir, -direction
+                                       Set the direction:
+                                           l, left
+                                           r, right
+
                      Use "all" to include all combinations; the default is to
-                     include no skin tones and the "person" gender.
+                     include no skin tones, the "person" gender, no hair style,
+                     and facing left. Only a few emojis support hair styles
+                     (person) or direction (walking, running, kneeling, etc.)
 
                      Note: emojis may not be accurately copied by select & copy
                      in terminals. It's recommended to copy to the clipboard
                      directly by piping to e.g. xclip.
 
+    emojify [text]   Replace shortcodes such as :tada: with the emoji. Slack's
+                     :+1::skin-tone-2: skin tone notation is also understood.
+                     Shortcodes that aren't found are left as-is.
+
+                     Use -emoticons to also replace ASCII emoticons such as :)
+                     and <3; they need to be surrounded by whitespace, but may
+                     be followed by punctuation, so "http://x" is left alone.
+
+    demojize [text]  Replace emojis with shortcodes, using the first set in
+                     -shortcodes that has one. Convert between platforms with
+                     e.g.:
+
+                         uni emojify -sc slack <in | uni demojize -sc github
+
+    unescape [text]  Decode escapes and print the text. Everything that's not
+                     an escape is printed as-is:
+
+                         \u2713 \u{2713}    JSON, JavaScript, Java, Python, etc.
+                         \U0001F600         Python, C, Go
+                         \x{2713}           Perl
+                         \xe2\x9c\x93       Bytes, as UTF-8
+                         %E2%9C%93          Bytes, as UTF-8 (URL encoding)
+                         &#x2713; &#10003;  HTML/XML character references
+                         &check;            HTML named entities
+                         \N{CHECK MARK}     Codepoint name or alias
+                         \2713              CSS
+                         \n \t \0 \\ etc.   Single-character escapes
+
+                     UTF-16 surrogate pairs such as \ud83d\ude00 are combined.
+                     Bytes that aren't valid UTF-8 are decoded as Latin-1, so
+                     \xe9 is é. Quoted strings such as "caf\u00e9" or u'caf\xe9'
+                     are unquoted.
+
+    escape [text]    Escape text for use in a string literal. Only characters
+                     that need to be escaped are escaped: quotes, backslashes,
+                     control characters, and invisible characters such as
+                     U+200B ZERO WIDTH SPACE or U+00A0 NO-BREAK SPACE.
+
+                     -lang     Language: go (default), python, js, json, rust,
+                               c, java, css, sql, url, html, or xml.
+                     -ascii    Escape all non-ASCII characters.
+
+                     The quotes aren't added; the SQL escapes only work in
+                     U&'..' strings.
+
+    mojibake [text]  Find mojibake: UTF-8 text that was decoded with the wrong
+                     encoding, such as "cafÃ©" or "Itâ€™s" instead of "café"
+                     or "It’s". This tries UTF-8 decoded as cp1252, latin1,
+                     macroman, or cp437, possibly twice ("cafÃƒÂ©"), and
+                     reports the most plausible repair with a confidence score
+                     and the codepoints that were changed.
+
+                     Use -fix to print only the repaired text; the text is
+                     printed unchanged if there's no mojibake or if the
+                     confidence is lower than 50%.
+
+    hexdump [text]   Print every UTF-8 sequence on its own line, with the
+                     offset, bytes, and the character and name. Invalid
+                     sequences are printed with the reason why they're
+                     invalid: stray continuation bytes, truncated sequences,
+                     overlong encodings, surrogates encoded in UTF-8 (CESU-8 or
+                     WTF-8), and values above U+10FFFF.
+
+                     The bytes are read as-is from the arguments, the file in
+                     -file, or stdin. Use -nonascii to only print non-ASCII
+                     characters and invalid sequences. The exit code is 1 if
+                     there are any invalid sequences.
+
+    audit [path ..]  Find suspicious characters in files, or all files in
+                     directories (skipping hidden directories and binary
+                     files). The default is the current directory. The rules
+                     are:
+
+                       bidi          Bidirectional control characters, which
+                                     can make code display differently from
+                                     how it's parsed (CVE-2021-42574).
+                       invisible     Zero-width and other default-ignorable
+                                     characters; emoji sequences and a byte
+                                     order mark at the start are allowed.
+                       tag           Tag characters outside of emoji flags.
+                       control       Control characters other than tab,
+                                     newline, carriage return, and form feed.
+                       nfc           Text that is not normalized to NFC.
+                       mixed-script  Identifiers that mix scripts, such as
+                                     Latin and Cyrillic.
+                       confusable    Characters that look like ASCII in a word
+                                     with ASCII letters, such as "pаypal".
+
+                     Every problem is printed as "file:line:col: rule: text",
+                     or use -as json or -as sarif. The exit code is 1 if there
+                     are any problems.
+
+                     Flags:
+
+                       -rules   Comma-separated list of rules to check;
+                                prefix with "-" to disable a rule and check
+                                all others: -rules=-nfc,-confusable
+                       -allow   Allowlist file. Every line has a list of
+                                codepoints, ranges, or rules that are allowed,
+                                optionally prefixed with a glob to only allow
+                                them in matching files. Codepoints need the U+
+                                prefix:
+
+                                    U+00A0 U+2018..U+201D
+                                    *.md confusable
+                                    testdata/*.txt bidi invisible
+
+                       -fix     Remove characters that are safe to remove:
+                                bidi embeddings, overrides, and isolates, zero
+                                width spaces and word joiners, byte order
+                                marks, and tag characters.
+
+    reveal [text]    Find and decode text hidden with invisible characters:
+
+                       tags                 Tag characters (U+E0020..U+E007E)
+                                            map to ASCII; tags in emoji flags
+                                            are skipped.
+                       variation selectors  Two or more variation selectors
+                                            encode bytes: U+FE00..U+FE0F are
+                                            0-15 and U+E0100..U+E01EF are
+                                            16-255.
+                       zero-width binary    Eight or more zero-width
+                                            characters (U+200B, U+200C, U+200D,
+                                            U+2060, U+FEFF, etc.) used as
+                                            binary digits, optionally with one
+                                            as a separator between characters.
+
+                     Every payload is printed as "line:col: kind: payload", or
+                     use -as json. The exit code is 1 if nothing was found.
+
+    stats [file ..]  Count the characters in the files, or stdin if there are
+                     no files, grouped by script, block, category, plane, and
+                     Unicode version, with the percentage of the total.
+
+                     -by       Comma-separated list of groups: script, block,
+                               category, plane, unicode, or codepoint. Use
+                               "all" for all groups, including codepoint.
+
+                     The -format flag can use %(count), %(percent), and
+                     %(name); for the codepoint group all the columns from
+                     the Format section below can be used. With more than
+                     one group every group gets a heading, or with -as json
+                     the output is an object with the group names as keys.
+
+    width [text]     Print the display width of every line of the input, as
+                     calculated by several engines:
+
+                       runewidth  Width of every codepoint; this is what the
+                                  %(cells) column uses.
+                       uniseg     Width of every grapheme cluster, which takes
+                                  emoji sequences and variation selectors into
+                                  account.
+                       eaw        The East Asian Width property of every
+                                  codepoint; 2 for wide and fullwidth, 0 for
+                                  marks and format characters, and 1 for
+                                  everything else.
+
+                     -by       line (default) or grapheme, to print the width
+                               of every grapheme cluster.
+                     -diff     Only print text where the engines disagree.
+
+                     The -format flag can use the engine names, %(text), and
+                     %(cpoints).
+
+    termprobe [query ..]
+                     Print characters to the terminal and measure how many
+                     cells they take up with a cursor position report (DSR),
+                     and compare that with the width engines from the width
+                     command. The query selects codepoints in the same way as
+                     print (codepoints, ranges, categories, blocks, scripts,
+                     or properties); use "emoji" for all emojis, or
+                     "emoji:group" for emojis in a group (e.g. "emoji:flags").
+
+                     -diff     Only print characters where the terminal
+                               disagrees with one of the engines.
+
+                     With -as json the output includes the terminal name from
+                     $TERM_PROGRAM or $TERM, so results from several terminals
+                     can be compared. The -format flag can use %(char),
+                     %(cpoints), %(name), %(terminal), %(term), and the engine
+                     names.
+
+    pick [codepoints|emoji] [query ..]
+                     Interactively search codepoints or emojis, with a preview
+                     of all properties for the selected row. Searching works
+                     the same as the search and emoji commands; the optional
+                     query is the initial search.
+
+                     Keys:
+                       Up, Down, ^P, ^N  Select previous or next row.
+                       PgUp, PgDn        Move 10 rows.
+                       Tab               Mark the row for multi-select.
+                       ^U, ^W            Clear the query, or the last word.
+                       ^E                Switch between codepoints and emoji.
+                       ^T                Cycle through skin tones.
+                       ^G                Cycle through genders.
+                       Enter             Print the marked rows, or the
+                                         selected row if nothing is marked.
+                       ^Y                Like Enter, but copy to the clipboard;
+                                         see -copy.
+                       Esc, ^C           Exit without printing anything; the
+                                         exit code is 1.
+
+                     The -tone, -gender, and -sc flags from the emoji command
+                     set the initial skin tone, gender, and shortcodes.
+
+    recent [n]       Print the n most recently used characters and emojis
+                     (default 20), most recent first. Characters copied with
+                     -copy or chosen in pick are remembered in
+                     $XDG_STATE_HOME/uni/history (~/.local/state/uni/history
+                     if $XDG_STATE_HOME isn't set), and are listed first in
+                     the search, emoji, and pick results.
+
+    fav [add|rm|list] [text ..]
+                     Add or remove favourite characters and emojis, or list
+                     them (the default). Every character or emoji in the text
+                     is a favourite; they're stored in
+                     $XDG_STATE_HOME/uni/favourites.
+
+    pua [import|list]
+                     List the name tables for the Private Use Areas for the
+                     -pua flag (the default), or import one from the name
+                     list of an icon font:
+
+                       uni pua import nerdfonts glyphnames.json
+                       uni -pua nerdfonts print U+E725
+
+                     The tables are stored in $XDG_DATA_HOME/uni/pua
+                     (~/.local/share/uni/pua if $XDG_DATA_HOME isn't set) as
+                     <name>.txt; the name is the format, unless it's given as
+                     the third argument. The formats are:
+
+                       nerdfonts    glyphnames.json from Nerd Fonts; names
+                                    are prefixed with "nf-".
+                       fontawesome  icons.json from Font Awesome; names are
+                                    prefixed with "fa-".
+                       codepoints   "name hex" lines, as used by Material
+                                    Icons and others.
+                       unicodedata  UnicodeData.txt format, as used by the
+                                    ConScript Unicode Registry.
+
+                     Tables have "U+E0A0 name" lines and # for comments, or
+                     are a JSON object such as {"E0A0": "name"}; you can also
+                     write your own.
+
 Format:
     You can use the -format or -f flag to control what to print; placeholders
     are in the form of %(name) or %(name flags), where "name" is a column name
@@ -236,9 +597,23 @@ Format:
         %(utf8)          As UTF-8                      e2 9c 93
         %(utf16le)       As UTF-16 LE (Windows)        13 27
         %(utf16be)       As UTF-16 BE                  27 13
+        %(utf32)         As UTF-32 BE                  00 00 27 13
+        %(latin1)        As ISO-8859-1, or blank if    e9 (for é)
+                         it can't be represented
+        %(cp1252)        As Windows-1252, or blank     80 (for €)
+        %(sjis)          As Shift JIS, or blank        82 a0 (for あ)
         %(html)          HTML entity (name or hex)     &check;
         %(xml)           XML entity                    &#x2713;
         %(json)          JSON escape                   \u2713
+        %(go)            Go escape                     \u2713
+        %(python)        Python escape                 \u2713
+        %(js)            JavaScript escape             \u2713
+        %(rust)          Rust escape                   \u{2713}
+        %(c)             C escape                      \u2713
+        %(java)          Java escape                   \u2713
+        %(css)           CSS escape                    \2713
+        %(url)           URL encoding                  %E2%9C%93
+        %(sql)           SQL escape, for U&'..'        \2713
         %(keysym)        X11 keysym; can be blank      checkmark
         %(digraph)       Vim Digraph; can be blank     OK
         %(name)          Code point name               CHECK MARK
@@ -256,6 +631,20 @@ Format:
         %(refs)          Reference other codepoints,   U+221A, U+1F5F8, U+1FBB1
                          usually similar/alternatives
 
+    Placeholders for identify; these are set for every codepoint in an emoji
+    sequence, and are blank for search and print:
+        %(region)        ISO 3166 code for flags       NL
+        %(base)          Emoji without modifiers       👍
+        %(tone)          Skin tone(s)                  dark skin tone
+        %(gender)        Gender(s)                     woman
+
+    Placeholders for identify with the position in the input; these are blank
+    for search and print:
+        %(offset)        Byte offset, starting at 0    42
+        %(line)          Line number                   3
+        %(col)           Column in bytes               7
+        %(cellcol)       Column in terminal cells      5
+
         The default is:
         `+defaultFormat+`
 
@@ -268,39 +657,110 @@ Format:
         %(cpoint)      Codepoints                      U+1F9D1 U+200D U+1F692
         %(cldr)        CLDR data, w/o emoji name       firetruck
         %(cldr_full)   Full CLDR data                  firefighter, firetruck
+        %(emoji_version)
+                       Emoji version it was added in   12.1
+        %(status)      Qualification status            fully-qualified
+        %(shortcode)   Shortcode; see -shortcodes      :firefighter:
+        %(emoticons)   ASCII emoticons, separated by   :) :-) =)
+                       a space; usually blank
+        %(region)      ISO 3166 code for flags         GB-SCT
 
         The default is:
         `+defaultEmojiFormat+`
+
+Config:
+    Defaults for flags can be set in $XDG_CONFIG_HOME/uni/config, or
+    ~/.config/uni/config if $XDG_CONFIG_HOME isn't set. Flags on the
+    commandline always take precedence. For example:
+
+        # Lines starting with # are comments. Keys before the first section
+        # apply to all commands.
+        tone   = mediumdark
+        format = %(char q l:3) %(cpoint l:7) %(name)
+
+        # Defaults for one command.
+        [emoji]
+        format = %(emoji) %(name)
+        gender = man
+
+        # Query aliases for print, search, and emoji: "uni print @arrows".
+        [aliases]
+        arrows = block:arrows block:supplemental-arrows-a
+
+        # Format presets: "uni search -f @mine euro".
+        [formats]
+        mine = %(char) %(dec) %(name)
+
+    The keys are format, as, or, tone, gender, hair, direction, shortcodes,
+    and pua. The format in the global section only applies to identify,
+    search, print, recent, and fav; other commands have different columns.
+    The value for format can also be a preset, "all", or start with "+".
+
+    Extra aliases for codepoints and keywords for emojis can be added in the
+    annotations file next to the config (~/.config/uni/annotations):
+
+        U+2713 = tick, done
+        🚢     = ship it, deploy
+
+    The codepoint can be in any format print accepts, or the character itself.
+    The aliases are searched by search and added to %(aliases), and the
+    keywords are searched by emoji and added to %(cldr). Modifiers such as skin
+    tones are ignored, so keywords for 👍🏽 also apply to 👍 and 👍🏿.
 `)
 
 const (
 	defaultFormat  = "%(char q h l:3)%(wide_padding) %(cpoint h l:7) %(dec l:6) %(utf8 l:11) %(html l:10) %(name t) %(aliases t h Q:[:])"
 	defaultCompact = "%(char q h l:3)%(wide_padding) %(cpoint h l:7) %(name t l:auto)"
 	allFormat      = "%(char q h l:3)%(wide_padding) %(cpoint h l:auto) %width %cells %dec %hex %oct %bin" +
-		" %utf8 %utf16le %utf16be %html %xml %json %keysym %digraph %name %plane %cat %block" +
-		" %script %props %unicode %aliases %refs"
+		" %utf8 %utf16le %utf16be %utf32 %latin1 %cp1252 %sjis %html %xml %json %go %python %js %rust %c %java %css %url %sql %keysym %digraph %name %plane %cat %block" +
+		" %script %props %unicode %aliases %refs %region %base %tone %gender %offset %line %col %cellcol"
 
 	defaultEmojiFormat  = "%(emoji h)%(tab)%name  %(cldr t Q:[:])"
 	defaultEmojiCompact = "%(emoji h)%(tab)%name"
-	allEmojiFormat      = "%(emoji h)%(tab)%name %group %subgroup %cpoint %cldr %(cldr_full)"
+	allEmojiFormat      = "%(emoji h)%(tab)%name %group %subgroup %cpoint %emoji_version %status %shortcode %emoticons %region %cldr %(cldr_full)"
 )
 
+var commands = []string{"list", "identify", "print", "search", "emoji", "emojify", "demojize",
+	"unescape", "escape", "mojibake", "hexdump", "audit", "reveal", "stats", "width", "termprobe",
+	"pick", "recent", "fav", "pua", "help", "version"}
+
 func main() {
 	flag := zli.NewFlags(os.Args)
 	var (
-		compact  = flag.Bool(false, "c", "compact", "q", "quiet")
-		help     = flag.Bool(false, "h", "help")
-		versionF = flag.Bool(false, "v", "version")
-		rawF     = flag.Bool(false, "r", "raw")
-		pager    = flag.Bool(false, "p", "pager")
-		or       = flag.Bool(false, "o", "or")
-		formatF  = flag.String(defaultFormat, "format", "f")
-		tone     = flag.String("", "t", "tone", "tones")
-		gender   = flag.String("person", "g", "gender", "genders")
-		asF      = flag.String("list", "a", "as")
-		jsonF    = flag.Bool(false, "json", "j")
+		compact   = flag.Bool(false, "c", "compact", "q", "quiet")
+		help      = flag.Bool(false, "h", "help")
+		versionF  = flag.Bool(false, "v", "version")
+		rawF      = flag.Bool(false, "r", "raw")
+		pager     = flag.Bool(false, "p", "pager")
+		or        = flag.Bool(false, "o", "or")
+		formatF   = flag.String(defaultFormat, "format", "f")
+		tone      = flag.String("", "t", "tone", "tones")
+		gender    = flag.String("person", "g", "gender", "genders")
+		hair      = flag.String("", "hair")
+		direction = flag.String("", "direction", "dir")
+		asF       = flag.String("list", "a", "as")
+		jsonF     = flag.Bool(false, "json", "j")
+		scF       = flag.String("", "sc", "shortcodes")
+		emoticonF = flag.Bool(false, "emoticons")
+		unescapeF = flag.Bool(false, "unescape")
+		langF     = flag.String("go", "lang")
+		asciiF    = flag.Bool(false, "ascii")
+		encodingF = flag.String("", "encoding")
+		fixF      = flag.Bool(false, "fix")
+		fileF     = flag.String("", "file")
+		nonASCIIF = flag.Bool(false, "nonascii")
+		filterF   = flag.String("", "filter")
+		rulesF    = flag.String("", "rules")
+		allowF    = flag.String("", "allow")
+		byF       = flag.String("", "by")
+		diffF     = flag.Bool(false, "diff")
+		ambF      = flag.String("", "ambiguous")
+		copyF     = flag.Bool(false, "copy")
+		copyFmtF  = flag.String("", "copy-format")
+		puaF      = flag.String("", "pua")
 	)
 	zli.F(flag.Parse())
+	parseAmbiguousFlag(ambF.String())
 	if versionF.Set() {
 		fmt.Println(version)
 		return
@@ -313,10 +773,26 @@ func main() {
 		return
 	}
 
-	cmd, err := flag.ShiftCommand("list", "ls", "identify", "print", "search", "emoji", "help", "version")
+	cmd, err := flag.ShiftCommand(append(commands, "ls")...)
 	if cmd == "ls" { // Alias because I keep typing "ls"
 		cmd = "list"
 	}
+	// "e" and "emo" have always meant "emoji", "s" has always meant "search",
+	// "p" has always meant "print", and "h" and "he" have always meant "help",
+	// so keep that working now that there's also "emojify", "stats", "pick",
+	// and "hexdump".
+	if amb := (zli.ErrCommandAmbiguous{}); errors.As(err, &amb) {
+		switch {
+		case strings.HasPrefix("emoji", amb.Cmd):
+			cmd, err = "emoji", nil
+		case strings.HasPrefix("help", amb.Cmd):
+			cmd, err = "help", nil
+		case amb.Cmd == "s":
+			cmd, err = "search", nil
+		case amb.Cmd == "p":
+			cmd, err = "print", nil
+		}
+	}
 	switch cmd {
 	case "":
 		if errors.As(err, &zli.ErrCommandNoneGiven{}) {
@@ -335,55 +811,164 @@ func main() {
 		return
 	}
 
+	// Flags not given on the commandline are read from the config.
+	conf, err := readConfig()
+	zli.F(err)
+	zli.F(readAnnotations())
 	var (
-		as    = parseAsFlags(compact, asF, jsonF)
+		formatC    = conf.String(cmd, "format", formatF)
+		toneC      = conf.String(cmd, "tone", tone)
+		genderC    = conf.String(cmd, "gender", gender)
+		hairC      = conf.String(cmd, "hair", hair)
+		directionC = conf.String(cmd, "direction", direction)
+		scC        = conf.String(cmd, "shortcodes", scF)
+		orC        = conf.Bool(cmd, "or", or)
+		puaC       = conf.String(cmd, "pua", puaF)
+	)
+	if puaC.String() != "" {
+		zli.F(loadPUA(puaC.String()))
+	}
+
+	var (
+		as    = parseAsFlags(compact, conf.String(cmd, "as", asF), jsonF)
 		quiet = compact.Set()
 		raw   = rawF.Set()
 		args  = flag.Args
 	)
+	if (as == printAsSARIF || as == printAsSARIFCompact) && cmd != "audit" {
+		zli.Fatalf("-as sarif only works for the audit command")
+	}
 	if cmd == "print" {
 		args, err = zli.InputOrArgs(args, " \t\n", quiet)
 		zli.F(err)
-	} else if cmd != "list" {
-		args, err = zli.InputOrArgs(args, "", quiet)
+	} else if (cmd == "identify" || cmd == "hexdump" || cmd == "reveal") && fileF.Set() {
+		if len(args) > 0 {
+			zli.Fatalf("can't use -file with arguments")
+		}
+	} else if !slices.Contains([]string{"list", "hexdump", "audit", "stats", "termprobe", "pick", "recent", "fav", "pua"}, cmd) { // hexdump needs the input as-is.
+		args, err = readInput(args, parseEncodingFlag(encodingF.String()), quiet)
+		zli.F(err)
+	}
+	if slices.Contains([]string{"search", "print", "emoji"}, cmd) {
+		args, err = conf.expandAliases(args)
 		zli.F(err)
 	}
 
-	format := formatF.String()
-	if !formatF.Set() && cmd == "emoji" {
+	formatArg, err := conf.preset(formatC.String())
+	zli.F(err)
+	format := formatArg
+	if !formatC.Set() && cmd == "emoji" {
 		format = defaultEmojiFormat
 	}
 
-	if formatF.String() == "all" {
+	if formatArg == "all" {
 		format = allFormat
 		if cmd == "emoji" {
 			format = allEmojiFormat
 		}
 	}
-	if strings.HasPrefix(formatF.String(), "+") {
+	if strings.HasPrefix(formatArg, "+") {
 		format = defaultCompact
 		if cmd == "emoji" {
 			format = defaultEmojiCompact
 		}
-		format += " " + formatF.String()[1:]
+		format += " " + formatArg[1:]
+	}
+	format = expandFormat(format)
+
+	// Collect the characters from Format, and all output for commands that
+	// don't use Format.
+	doCopy := (copyF.Bool() || copyFmtF.Set()) && cmd != "pick"
+	output := new(strings.Builder)
+	if doCopy {
+		copyLines, copied, copyFormat = true, nil, copyFmtF.String()
+		if copyFormat != "" && !strings.Contains(copyFormat, "%") {
+			copyFormat = "%(" + copyFormat + ")"
+		}
+		copyFormat = expandFormat(copyFormat)
+
+		stdout := zli.Stdout
+		zli.Stdout = io.MultiWriter(stdout, output)
+		defer func() { zli.Stdout, copyLines = stdout, false }()
 	}
-	// Replace %name shortcut with %(name l:auto)
-	format = regexp.MustCompile(`%[a-z0-9-]+`).ReplaceAllStringFunc(format, func(s string) string {
-		return "%(" + s[1:] + " l:auto)"
-	})
 
 	switch cmd {
 	case "list":
 		err = list(args, as)
 	case "identify":
-		err = identify(args, format, raw, as)
+		if unescapeF.Bool() {
+			args, err = unescapeArgs(args)
+			zli.F(errors.Unwrap(err))
+		}
+		var filter filterFunc
+		filter, err = parseFilter(nonASCIIF.Bool(), filterF.String())
+		zli.F(err)
+		if fileF.Set() {
+			err = identifyFile(fileF.String(), parseEncodingFlag(encodingF.String()), format, raw, as, filter)
+		} else {
+			err = identify(args, format, raw, as, filter)
+		}
 	case "search":
-		err = search(args, format, raw, as, or.Bool())
+		err = search(args, format, raw, as, orC)
 	case "print":
 		err = print(args, format, raw, as)
 	case "emoji":
-		err = emoji(args, format, raw, as, or.Bool(),
-			parseToneFlag(tone.String()), parseGenderFlag(gender.String()))
+		err = emoji(args, format, raw, as, orC,
+			parseToneFlag(toneC.String()), parseGenderFlag(genderC.String()),
+			parseHairFlag(hairC.String()), parseDirectionFlag(directionC.String()),
+			parseShortcodeFlag(scC.String()))
+	case "emojify":
+		text := unidata.Emojify(strings.Join(args, " "), parseShortcodeFlag(scC.String())...)
+		if emoticonF.Bool() {
+			text = unidata.ReplaceEmoticons(text)
+		}
+		fmt.Fprintln(zli.Stdout, text)
+	case "demojize":
+		fmt.Fprintln(zli.Stdout, unidata.Demojize(strings.Join(args, " "), parseShortcodeFlag(scC.String())...))
+	case "unescape":
+		text, err := unidata.ParseEscapes(strings.Join(args, " "))
+		zli.F(errors.Unwrap(err))
+		fmt.Fprintln(zli.Stdout, string(text))
+	case "escape":
+		lang, ok := unidata.FindEscapeLang(langF.String())
+		if !ok {
+			zli.Fatalf("invalid value for -lang: %q", langF.String())
+		}
+		fmt.Fprintln(zli.Stdout, unidata.Escape(strings.Join(args, " "), lang, asciiF.Bool()))
+	case "mojibake":
+		err = mojibake(args, fixF.Bool())
+	case "hexdump":
+		err = hexdump(args, fileF.String(), nonASCIIF.Bool())
+	case "audit":
+		conf := auditConfig{fix: fixF.Bool()}
+		conf.rules, err = parseAuditRules(rulesF.String())
+		zli.F(err)
+		conf.allow, err = readAllowlist(allowF.String())
+		zli.F(err)
+		err = audit(args, conf, as)
+	case "reveal":
+		err = reveal(args, fileF.String(), as)
+	case "stats":
+		var by []string
+		by, err = parseStatsGroups(byF.String())
+		zli.F(err)
+		err = stats(args, parseEncodingFlag(encodingF.String()), by, format, formatC.Set(), raw, as)
+	case "width":
+		err = width(args, byF.String(), format, formatC.Set(), diffF.Bool(), as)
+	case "termprobe":
+		err = termprobe(args, format, formatC.Set(), diffF.Bool(), raw, as)
+	case "pick":
+		err = pick(args, parseToneFlag(toneC.String()), parseGenderFlag(genderC.String()),
+			parseShortcodeFlag(scC.String()), raw, copyF.Bool())
+	case "recent":
+		err = recent(args, format, raw, as)
+	case "fav":
+		err = fav(args, format, raw, as)
+	case "pua":
+		err = pua(args)
+	}
+	if err == errCanceled {
+		zli.Exit(1)
 	}
 	if err != nil {
 		if !(err == errNoMatches && quiet) {
@@ -391,6 +976,28 @@ func main() {
 		}
 		zli.Exit(1)
 	}
+
+	if doCopy {
+		text := strings.Join(copied, "")
+		if copyFormat != "" {
+			text = strings.Join(copied, "\n")
+		}
+		if text == "" && copyFormat == "" {
+			text = strings.TrimSuffix(output.String(), "\n")
+		}
+		if text == "" {
+			zli.Fatalf("-copy: nothing to copy")
+		}
+		zli.F(copyToClipboard(text))
+
+		// Only remember characters and emojis, not the output of emojify and
+		// the like.
+		if copyFormat == "" && len(copied) > 0 {
+			if err := recordHistory(copied); err != nil {
+				zli.Errorf("recording history: %s", err)
+			}
+		}
+	}
 }
 
 type fb interface {
@@ -402,6 +1009,15 @@ type fs interface {
 	String() string
 }
 
+var reShortcut = regexp.MustCompile(`%[a-z0-9_-]+`)
+
+// expandFormat replaces the %name shortcut with %(name l:auto).
+func expandFormat(format string) string {
+	return reShortcut.ReplaceAllStringFunc(format, func(s string) string {
+		return "%(" + s[1:] + " l:auto)"
+	})
+}
+
 func parseAsFlags(compact fb, asF fs, jsonF fb) printAs {
 	if jsonF.Set() {
 		if compact.Set() {
@@ -420,6 +1036,8 @@ func parseAsFlags(compact fb, asF fs, jsonF fb) printAs {
 		as = printAsJSON
 	case "t", "tbl", "table":
 		as = printAsTable
+	case "sarif":
+		as = printAsSARIF
 	}
 
 	if compact.Set() {
@@ -482,6 +1100,95 @@ func parseGenderFlag(gender string) unidata.EmojiModifier {
 	return m
 }
 
+func parseShortcodeFlag(sc string) []unidata.ShortcodeSet {
+	if sc == "" {
+		return []unidata.ShortcodeSet{unidata.ShortcodeGitHub, unidata.ShortcodeSlack,
+			unidata.ShortcodeDiscord, unidata.ShortcodeCLDR}
+	}
+
+	var sets []unidata.ShortcodeSet
+	for _, s := range strings.Split(sc, ",") {
+		set, ok := unidata.FindShortcodeSet(strings.TrimSpace(s))
+		if !ok {
+			zli.Fatalf("invalid value for -shortcodes: %q", s)
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func parseHairFlag(hair string) unidata.EmojiModifier {
+	if hair == "" {
+		return 0
+	}
+	if hair == "all" {
+		hair = "none,red,curly,white,bald"
+	}
+
+	var m unidata.EmojiModifier
+	for _, h := range zstring.Fields(hair, ",") {
+		switch h {
+		case "none", "n":
+			m |= unidata.ModNoHair
+		case "red", "r":
+			m |= unidata.ModRedHair
+		case "curly", "c":
+			m |= unidata.ModCurlyHair
+		case "white", "w":
+			m |= unidata.ModWhiteHair
+		case "bald", "b":
+			m |= unidata.ModBald
+		default:
+			zli.Fatalf("invalid hair style: %q", hair)
+		}
+	}
+	return m
+}
+
+// Width of ambiguous characters detected from the locale by runewidth.
+var ambiguousLocale = runewidth.EastAsianWidth
+
+// parseAmbiguousFlag sets the width of ambiguous characters from -ambiguous or
+// $UNI_AMBIGUOUS. If neither is set it's detected from the locale.
+func parseAmbiguousFlag(amb string) {
+	if amb == "" {
+		amb = os.Getenv("UNI_AMBIGUOUS")
+	}
+	wide := ambiguousLocale
+	if amb != "" {
+		m, err := match(amb, "narrow", "wide")
+		if err != nil {
+			zli.Fatalf("invalid value for -ambiguous: %q", amb)
+		}
+		wide = m == "wide"
+	}
+
+	unidata.SetAmbiguousWide(wide)
+	uniseg.EastAsianAmbiguousWidth = map[bool]int{true: 2, false: 1}[wide]
+}
+
+func parseDirectionFlag(dir string) unidata.EmojiModifier {
+	if dir == "" {
+		return 0
+	}
+	if dir == "all" {
+		dir = "left,right"
+	}
+
+	var m unidata.EmojiModifier
+	for _, d := range zstring.Fields(dir, ",") {
+		switch d {
+		case "left", "l":
+			m |= unidata.ModFacingLeft
+		case "right", "r":
+			m |= unidata.ModFacingRight
+		default:
+			zli.Fatalf("invalid direction: %q", dir)
+		}
+	}
+	return m
+}
+
 // TODO: move to zli or zstd; this is a copy of ShiftCommand() basically.
 //
 // Actually, f.StringMatch(...) might make sense, since this is a string value.
@@ -716,10 +1423,10 @@ func list(ls []string, as printAs) error {
 	return nil
 }
 
-func identify(ins []string, format string, raw bool, as printAs) error {
+func identify(ins []string, format string, raw bool, as printAs, filter filterFunc) error {
 	in := strings.Join(ins, "")
 	if !utf8.ValidString(in) {
-		fmt.Fprintf(zli.Stderr, "uni: WARNING: input string is not valid UTF-8\n")
+		fmt.Fprintf(zli.Stderr, "uni: WARNING: input string is not valid UTF-8; use -encoding to set the encoding\n")
 	}
 
 	f, err := NewFormat(format, as, knownColumns...)
@@ -727,35 +1434,95 @@ func identify(ins []string, format string, raw bool, as printAs) error {
 		return err
 	}
 
-	for _, c := range in {
-		info, ok := unidata.Find(c)
-		if !ok {
-			return fmt.Errorf("unknown codepoint: U+%.4X", c) // Should never happen.
-		}
-		f.Line(info.Codepoint, f.toLine(info, raw))
+	pos := newPosition()
+	err = identifyText(f, in, &pos, raw, filter)
+	if err != nil {
+		return err
 	}
 	f.Print(zli.Stdout)
 	return nil
 }
 
+// sequenceColumns gets the columns that depend on the full emoji sequence
+// rather than a single codepoint; every codepoint in the sequence gets the same
+// value.
+func sequenceColumns(runes []rune) []map[string]string {
+	var (
+		cols = make([]map[string]string, len(runes))
+		set  = func(i, n int, base unidata.Emoji, mods unidata.EmojiModifier, region string) int {
+			c := map[string]string{
+				"base":   base.String(),
+				"tone":   (mods & unidata.ModAllTones).String(),
+				"gender": (mods & unidata.ModAllGenders).String(),
+				"region": region,
+			}
+			for k := i; k < i+n; k++ {
+				cols[k] = c
+			}
+			return i + n - 1
+		}
+	)
+	for i := 0; i < len(runes); i++ {
+		// Flags that aren't in the emoji list still have a region.
+		if region, n := unidata.FlagRegion(runes[i:]); n > 0 {
+			base, _, _ := unidata.ParseEmoji(string(runes[i : i+n]))
+			i = set(i, n, base, 0, region)
+			continue
+		}
+
+		// Find the longest emoji sequence; 10 codepoints is enough for
+		// everything up to "kiss: woman, man, light skin tone, dark skin tone".
+		for j := min(len(runes), i+10); j > i; j-- {
+			if base, mods, ok := unidata.ParseEmoji(string(runes[i:j])); ok {
+				i = set(i, j-i, base, mods, "")
+				break
+			}
+		}
+	}
+	return cols
+}
+
 func search(args []string, format string, raw bool, as printAs, or bool) error {
 	args = slices.DeleteFunc(args, func(s string) bool { return s == "" })
 	if len(args) == 0 {
 		return errors.New("search: need search term")
 	}
-	for i := range args {
-		args[i] = strings.ToUpper(args[i])
+
+	found := searchCodepoints(args, or)
+	if len(found) == 0 {
+		return errNoMatches
 	}
 
-	found := false
 	f, err := NewFormat(format, as, knownColumns...)
 	if err != nil {
 		return err
 	}
+	// Recently used characters first.
+	recent := recentRanks()
+	slices.SortFunc(found, func(a, b unidata.Codepoint) int {
+		return cmp.Or(compareRecent(recent, string(a.Codepoint), string(b.Codepoint)),
+			cmp.Compare(a.Codepoint, b.Codepoint))
+	})
+	for _, info := range found {
+		f.Line(info.Codepoint, f.toLine(info, raw))
+	}
+	f.Print(zli.Stdout)
+	return nil
+}
 
-	for _, info := range unidata.Codepoints {
+// searchCodepoints finds all codepoints where the name or one of the aliases
+// contains all of the words, or one of the words if or is set. The result is
+// not sorted.
+func searchCodepoints(words []string, or bool) []unidata.Codepoint {
+	upper := make([]string, 0, len(words))
+	for _, w := range words {
+		upper = append(upper, strings.ToUpper(w))
+	}
+
+	var found []unidata.Codepoint
+	match := func(info unidata.Codepoint, name string) {
 		hasAlias := func(upperS string) bool {
-			for _, a := range info.Aliases() {
+			for _, a := range aliases(info) {
 				if strings.Contains(strings.ToUpper(a), upperS) {
 					return true
 				}
@@ -764,28 +1531,31 @@ func search(args []string, format string, raw bool, as printAs, or bool) error {
 		}
 
 		m := 0
-		for _, a := range args {
-			if strings.Contains(info.Name(), a) || hasAlias(a) {
+		for _, a := range upper {
+			if strings.Contains(name, a) || hasAlias(a) {
 				if or {
-					found = true
-					f.Line(info.Codepoint, f.toLine(info, raw))
+					found = append(found, info)
 					break
 				}
 				m++
 			}
 		}
-		if !or && m == len(args) {
-			found = true
-			f.Line(info.Codepoint, f.toLine(info, raw))
+		if !or && m == len(upper) {
+			found = append(found, info)
 		}
 	}
-
-	if !found {
-		return errNoMatches
+	for _, info := range unidata.Codepoints {
+		if _, ok := puaNames[info.Codepoint]; !ok {
+			match(info, info.Name())
+		}
 	}
-	f.SortCodepoint()
-	f.Print(zli.Stdout)
-	return nil
+	// Names from -pua are usually lower-case, and aren't in the Codepoints
+	// map.
+	for cp := range puaNames {
+		info, _ := unidata.Find(cp)
+		match(info, strings.ToUpper(info.Name()))
+	}
+	return found
 }
 
 var utfClean = strings.NewReplacer("0x", "", " ", "", "_", "", "-", "")
@@ -800,12 +1570,53 @@ func nbools(bools ...bool) int {
 	return n
 }
 
+func unescapeArgs(args []string) ([]string, error) {
+	for i := range args {
+		r, err := unidata.ParseEscapes(args[i])
+		if err != nil {
+			return nil, err
+		}
+		args[i] = string(r)
+	}
+	return args, nil
+}
+
+var reURLEscapes = regexp.MustCompile(`^(?:%[0-9a-fA-F]{2}){2,}$`)
+
+// isURLEscapedUTF8 reports if s is only URL escapes that form valid UTF-8,
+// such as %E2%9C%93.
+func isURLEscapedUTF8(s string) bool {
+	if !reURLEscapes.MatchString(s) {
+		return false
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "%", ""))
+	return err == nil && utf8.Valid(b)
+}
+
 func print(args []string, format string, raw bool, as printAs) error {
 	f, err := NewFormat(format, as, knownColumns...)
 	if err != nil {
 		return err
 	}
 	for _, a := range args {
+		// Escapes such as \u2713 or &#x2713;. URL escapes are only used for
+		// UTF-8 sequences such as %E2%9C%93, as %2713 is the hex notation
+		// below.
+		r, err := []rune(a), error(nil)
+		if !strings.HasPrefix(a, "%") || isURLEscapedUTF8(a) {
+			r, err = unidata.ParseEscapes(a)
+			if err != nil {
+				return errors.Unwrap(err)
+			}
+		}
+		if string(r) != a {
+			for _, c := range r {
+				info, _ := unidata.Find(c)
+				f.Line(info.Codepoint, f.toLine(info, raw))
+			}
+			continue
+		}
+
 		a = strings.Trim(strings.ToLower(a), ",/")
 		if a == "" {
 			continue
@@ -850,163 +1661,23 @@ func print(args []string, format string, raw bool, as printAs) error {
 			continue
 		}
 
-		// Find by block, category, or property.
-		var (
-			catOk, blOk, pOk, scOk bool
-			cat                    unidata.Category
-			bl                     unidata.Block
-			p                      unidata.Property
-			sc                     unidata.Script
-		)
-		switch {
-		case zstring.HasPrefixes(a, "block:", "b:"):
-			a = a[strings.IndexByte(a, ':')+1:]
-			bl, blOk = unidata.FindBlock(a)
-			if !blOk {
-				zli.Fatalf("unknown or ambiguous block: %q", a)
-			}
-		case zstring.HasPrefixes(a, "script:", "s:"):
-			a = a[strings.IndexByte(a, ':')+1:]
-			sc, scOk = unidata.FindScript(a)
-			if !scOk {
-				zli.Fatalf("unknown or ambiguous script: %q", a)
-			}
-		case zstring.HasPrefixes(a, "category:", "cat:", "c:"):
-			a = a[strings.IndexByte(a, ':')+1:]
-			cat, catOk = unidata.FindCategory(a)
-			if !catOk {
-				zli.Fatalf("unknown or ambiguous category: %q", a)
-			}
-		case zstring.HasPrefixes(a, "property:", "prop:", "p:"):
-			a = a[strings.IndexByte(a, ':')+1:]
-			p, pOk = unidata.FindProperty(a)
-			if !pOk {
-				zli.Fatalf("unknown or ambiguous property: %q", a)
-			}
-		default:
-			cat, catOk = unidata.FindCategory(a)
-			bl, blOk = unidata.FindBlock(a)
-			p, pOk = unidata.FindProperty(a)
-			if nbools(catOk, blOk, pOk) > 1 {
-				opt := make([]string, 0, 3)
-				if catOk {
-					opt = append(opt, fmt.Sprintf("Category(%q)", cat))
-				}
-				if blOk {
-					opt = append(opt, fmt.Sprintf("Block(%q)", bl))
-				}
-				if pOk {
-					opt = append(opt, fmt.Sprintf("Property(%q)", p))
-				}
-				zli.Fatalf("%q matched multiple options:\n\t%s\nPrefix with 'block:', 'category:', or 'property:'",
-					a, strings.Join(opt, ", "))
-			}
-		}
-
-		// Category name.
-		if catOk {
-			cc := unidata.Categories[cat]
-			if as == printAsList || as == printAsTable {
-				fmt.Fprintf(zli.Stdout, "Showing category %s (%s)\n", cc.ShortName, cc.Name)
-			}
-
-			for _, info := range unidata.Codepoints {
-				if info.Category() == cat {
-					f.Line(info.Codepoint, f.toLine(info, raw))
-				}
-				for _, incl := range cc.Include {
-					if info.Category() == incl {
-						f.Line(info.Codepoint, f.toLine(info, raw))
-					}
-				}
-			}
-			continue
-		}
-		// Script.
-		if scOk {
-			cc := unidata.Scripts[sc]
-			if as == printAsList || as == printAsTable {
-				fmt.Fprintf(zli.Stdout, "Showing script %s\n", cc.Name)
-			}
-
-			for _, pp := range cc.Ranges {
-				for cp := pp[0]; cp <= pp[1]; cp++ {
-					s, ok := unidata.Codepoints[cp]
-					if ok {
-						f.Line(cp, f.toLine(s, raw))
-					}
-				}
-			}
-
-			continue
-		}
-
-		// Block.
-		if blOk {
-			if as == printAsList || as == printAsTable {
-				fmt.Fprintf(zli.Stdout, "Showing block %s\n", bl)
-			}
-
-			for cp := unidata.Blocks[bl].Range[0]; cp <= unidata.Blocks[bl].Range[1]; cp++ {
-				s, ok := unidata.Codepoints[cp]
-				if ok {
-					f.Line(cp, f.toLine(s, raw))
-				}
-			}
-			continue
-		}
-		// Properties
-		if pOk {
-			if as == printAsList || as == printAsTable {
-				fmt.Fprintf(zli.Stdout, "Showing property %s\n", p)
-			}
-
-			for _, pp := range unidata.Properties[p].Ranges {
-				for cp := pp[0]; cp <= pp[1]; cp++ {
-					s, ok := unidata.Codepoints[cp]
-					if ok {
-						f.Line(cp, f.toLine(s, raw))
-					}
-				}
-			}
-			continue
-		}
-
-		// U2042, U+2042, U+2042..U+2050, 2042..2050, 2042-2050, 0x2041, etc.
-		var s []string
-		switch {
-		case strings.Contains(a, ".."):
-			s = strings.SplitN(a, "..", 2)
-		case strings.Contains(a, "-"):
-			s = strings.SplitN(a, "-", 2)
-		default:
-			s = []string{a, a}
-		}
-		s[0], s[1] = strings.TrimSpace(s[0]), strings.TrimSpace(s[1])
-
-		start, err := unidata.FromString(s[0])
-		if err != nil {
-			return fmt.Errorf("invalid codepoint: %s", errors.Unwrap(err))
-		}
-		end, err := unidata.FromString(s[1])
+		q, err := parseQuery(a)
 		if err != nil {
-			return fmt.Errorf("invalid codepoint: %s", errors.Unwrap(err))
+			return err
 		}
-		if start.Codepoint > end.Codepoint {
-			zli.Fatalf("end of range %q is lower than start %q", s[1], s[0])
-		}
-
-		for i := start.Codepoint; i <= end.Codepoint; i++ {
-			info, _ := unidata.Find(i)
-			f.Line(info.Codepoint, f.toLine(info, raw))
+		if q.desc != "" && (as == printAsList || as == printAsTable) {
+			fmt.Fprintf(zli.Stdout, "Showing %s\n", q.desc)
 		}
+		q.each(func(info unidata.Codepoint) { f.Line(info.Codepoint, f.toLine(info, raw)) })
 	}
 	f.SortCodepoint()
 	f.Print(zli.Stdout)
 	return nil
 }
 
-func emoji(args []string, format string, raw bool, as printAs, or bool, tones, genders unidata.EmojiModifier) error {
+func emoji(args []string, format string, raw bool, as printAs, or bool,
+	tones, genders, hair, dir unidata.EmojiModifier, sets []unidata.ShortcodeSet,
+) error {
 	if as == printAsTable || as == printAsTableCompact {
 		// TODO: it should
 		// The reason it doesn't work is because printTbl() assumes that every
@@ -1015,32 +1686,114 @@ func emoji(args []string, format string, raw bool, as printAs, or bool, tones, g
 		return errors.New("-as table doesn't work with the emoji command")
 	}
 
+	out, err := findEmojis(args, or, tones, genders, hair, dir, sets)
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return errNoMatches
+	}
+
+	recent := recentRanks()
+	slices.SortStableFunc(out, func(a, b unidata.Emoji) int { return compareRecent(recent, a.String(), b.String()) })
+
+	f, err := NewFormat(format, as, emojiColumnNames...)
+	if err != nil {
+		return err
+	}
+	for _, e := range out {
+		f.Line(0, emojiColumns(e, sets))
+	}
+	f.Print(zli.Stdout)
+	return nil
+}
+
+// findEmojis finds all emojis matching the arguments, in the same way as the
+// emoji command.
+func findEmojis(args []string, or bool,
+	tones, genders, hair, dir unidata.EmojiModifier, sets []unidata.ShortcodeSet,
+) ([]unidata.Emoji, error) {
 	type matchArg struct {
-		group bool
-		name  bool
-		text  string
+		group     bool
+		name      bool
+		status    bool
+		version   bool
+		shortcode bool
+		op        string
+		text      string
 	}
 	var (
 		all       = slices.Contains(args, "all")
 		matchArgs = make([]matchArg, 0, len(args))
+		flags     = make([]unidata.Emoji, 0, 1)
+		hasStatus = false
 	)
-	if !all {
-		for _, a := range args {
-			a := strings.ToLower(a)
-			group := strings.HasPrefix(a, "g:") || strings.HasPrefix(a, "group:")
-			if group {
-				a = strings.TrimPrefix(strings.TrimPrefix(a, "group:"), "g:")
+	for _, a := range args {
+		a := strings.ToLower(a)
+		if a == "all" {
+			continue
+		}
+		if strings.HasPrefix(a, "flag:") {
+			code := strings.TrimPrefix(a, "flag:")
+			f, err := unidata.Flag(code)
+			if err != nil {
+				return nil, errors.New(strings.TrimPrefix(err.Error(), "unidata.Flag: "))
 			}
-			name := strings.HasPrefix(a, "n:") || strings.HasPrefix(a, "name:")
-			if name {
-				a = strings.TrimPrefix(strings.TrimPrefix(a, "name:"), "n:")
+			flags = append(flags, f)
+			continue
+		}
+		group := strings.HasPrefix(a, "g:") || strings.HasPrefix(a, "group:")
+		if group {
+			a = strings.TrimPrefix(strings.TrimPrefix(a, "group:"), "g:")
+		}
+		name := strings.HasPrefix(a, "n:") || strings.HasPrefix(a, "name:")
+		if name {
+			a = strings.TrimPrefix(strings.TrimPrefix(a, "name:"), "n:")
+		}
+		status := strings.HasPrefix(a, "status:")
+		if status {
+			a, hasStatus = strings.TrimPrefix(a, "status:"), true
+		}
+		shortcode := strings.HasPrefix(a, "sc:") || strings.HasPrefix(a, "shortcode:")
+		if shortcode {
+			a = strings.Trim(strings.TrimPrefix(strings.TrimPrefix(a, "shortcode:"), "sc:"), ":")
+		}
+		version := strings.HasPrefix(a, "v:") || strings.HasPrefix(a, "version:")
+		var op string
+		if version {
+			a = strings.TrimPrefix(strings.TrimPrefix(a, "version:"), "v:")
+			for _, o := range []string{">=", "<=", ">", "<", "="} {
+				if strings.HasPrefix(a, o) {
+					op, a = o, a[len(o):]
+					break
+				}
+			}
+			if _, _, ok := parseVersion(a); !ok {
+				return nil, fmt.Errorf("invalid emoji version: %q", a)
 			}
-			matchArgs = append(matchArgs, matchArg{text: a, group: group, name: name})
 		}
+		if all && !status && !version {
+			continue
+		}
+		matchArgs = append(matchArgs, matchArg{text: a, group: group, name: name,
+			status: status, version: version, shortcode: shortcode, op: op})
+	}
+	if all && len(matchArgs) > 0 {
+		all = false
 	}
 
 	out := make([]unidata.Emoji, 0, 16)
+	out = append(out, flags...)
 	for _, e := range unidata.Emojis {
+		if len(flags) > 0 && !all && len(matchArgs) == 0 {
+			break
+		}
+		// Only show minimally-qualified and unqualified emojis if explicitly
+		// asked for.
+		if !hasStatus && (e.Status() == unidata.StatusMinimallyQualified || e.Status() == unidata.StatusUnqualified) {
+			continue
+		}
+
 		m := 0
 		for _, a := range matchArgs {
 			var match bool
@@ -1050,9 +1803,21 @@ func emoji(args []string, format string, raw bool, as printAs, or bool, tones, g
 					strings.Contains(strings.ToLower(e.Subgroup().String()), a.text)
 			case a.name:
 				match = strings.Contains(strings.ToLower(e.Name), a.text)
+			case a.status:
+				match = strings.HasPrefix(e.Status().String(), a.text)
+			case a.version:
+				match = matchVersion(e.Version().String(), a.op, a.text)
+			case a.shortcode:
+				for _, s := range sets {
+					if slices.Contains(e.Shortcodes(s), a.text) {
+						match = true
+						break
+					}
+				}
 			default:
 				match = strings.Contains(strings.ToLower(e.Name), a.text) ||
-					slices.Contains(e.CLDR, a.text)
+					slices.Contains(cldr(e), a.text) ||
+					slices.ContainsFunc(e.Emoticons(), func(em string) bool { return strings.EqualFold(em, a.text) })
 			}
 			if match {
 				m++
@@ -1063,59 +1828,103 @@ func emoji(args []string, format string, raw bool, as printAs, or bool, tones, g
 			}
 		}
 		if all || (!or && m == len(matchArgs)) {
-			out = append(out, applyGenders(applyTones(e, tones), genders)...)
+			out = append(out, applyDirections(applyHair(applyGenders(applyTones(e, tones), genders), hair), dir)...)
 		}
 	}
 
-	if len(out) == 0 {
-		return errNoMatches
+	return out, nil
+}
+
+// Columns for emojiColumns().
+var emojiColumnNames = []string{"emoji", "name", "group", "subgroup",
+	"tab", "cldr", "cldr_full", "cpoint", "emoji_version", "status", "shortcode", "emoticons", "region"}
+
+// emojiColumns gets the columns to print for an emoji.
+func emojiColumns(e unidata.Emoji, sets []unidata.ShortcodeSet) map[string]string {
+	keywords := cldr(e)
+	return map[string]string{
+		"emoji":    e.String(),
+		"name":     e.Name,
+		"group":    e.Group().String(),
+		"subgroup": e.Subgroup().String(),
+		"tab":      tabOrSpace(),
+		"cldr": func() string {
+			// Remove words that duplicate what's already in the name; it's
+			// kind of pointless.
+			cldr := make([]string, 0, len(keywords))
+			for _, c := range keywords {
+				if !strings.Contains(e.Name, c) {
+					cldr = append(cldr, c)
+				}
+			}
+			return strings.Join(cldr, ", ")
+		}(),
+		"cldr_full": strings.Join(keywords, ", "),
+		"cpoint": func() string {
+			cp := make([]string, 0, len(e.Codepoints))
+			for _, c := range e.String() { // String() inserts ZWJ and whatnot
+				cp = append(cp, fmt.Sprintf("U+%04X", c))
+			}
+			return strings.Join(cp, " ")
+		}(),
+		"emoji_version": e.Version().String(),
+		"status":        e.Status().String(),
+		"emoticons":     strings.Join(e.Emoticons(), " "),
+		"region":        e.Region(),
+		"shortcode": func() string {
+			for _, s := range sets {
+				if sc := e.Shortcodes(s); len(sc) > 0 {
+					return ":" + sc[0] + ":"
+				}
+			}
+			return ""
+		}(),
 	}
+}
 
-	f, err := NewFormat(format, as, "emoji", "name", "group", "subgroup",
-		"tab", "cldr", "cldr_full", "cpoint")
+// parseVersion parses a version in the form of "15" or "15.1".
+func parseVersion(v string) (int, int, bool) {
+	maj, min, _ := strings.Cut(v, ".")
+	a, err := strconv.Atoi(maj)
 	if err != nil {
-		return err
+		return 0, 0, false
 	}
-	for _, e := range out {
-		f.Line(0, map[string]string{
-			"emoji":    e.String(),
-			"name":     e.Name,
-			"group":    e.Group().String(),
-			"subgroup": e.Subgroup().String(),
-			"tab":      tabOrSpace(),
-			"cldr": func() string {
-				// Remove words that duplicate what's already in the name; it's
-				// kind of pointless.
-				cldr := make([]string, 0, len(e.CLDR))
-				for _, c := range e.CLDR {
-					if !strings.Contains(e.Name, c) {
-						cldr = append(cldr, c)
-					}
-				}
-				return strings.Join(cldr, ", ")
-			}(),
-			"cldr_full": strings.Join(e.CLDR, ", "),
-			"cpoint": func() string {
-				cp := make([]string, 0, len(e.Codepoints))
-				for _, c := range e.String() { // String() inserts ZWJ and whatnot
-					cp = append(cp, fmt.Sprintf("U+%04X", c))
-				}
-				return strings.Join(cp, " ")
-			}(),
-		})
+	var b int
+	if min != "" {
+		b, err = strconv.Atoi(min)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return a, b, true
+}
+
+// matchVersion reports if the version have matches the version want with the
+// operator op (">=", "<=", ">", "<", or "="). An empty op is the same as "=".
+func matchVersion(have, op, want string) bool {
+	hMaj, hMin, _ := parseVersion(have)
+	wMaj, wMin, _ := parseVersion(want)
+	c := cmp.Or(cmp.Compare(hMaj, wMaj), cmp.Compare(hMin, wMin))
+	switch op {
+	case ">=":
+		return c >= 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case "<":
+		return c < 0
+	default:
+		return c == 0
 	}
-	f.Print(zli.Stdout)
-	return nil
 }
 
 func applyAll(e unidata.Emoji, mod unidata.EmojiModifier) []unidata.Emoji {
 	emojis := make([]unidata.Emoji, 0, 1)
-	i := unidata.EmojiModifier(1)
-	for i <= unidata.ModDark {
+	for i := unidata.EmojiModifier(1); i != 0 && i <= mod; i <<= 1 {
 		if mod&i != 0 {
 			emojis = append(emojis, e.With(i))
 		}
-		i <<= 1
 	}
 	return emojis
 }
@@ -1142,3 +1951,35 @@ func applyGenders(emojis []unidata.Emoji, mod unidata.EmojiModifier) []unidata.E
 	}
 	return ret
 }
+
+func applyHair(emojis []unidata.Emoji, mod unidata.EmojiModifier) []unidata.Emoji {
+	if mod == 0 {
+		return emojis
+	}
+
+	var ret []unidata.Emoji
+	for _, e := range emojis {
+		if !e.HairStyles() {
+			ret = append(ret, e)
+			continue
+		}
+		ret = append(ret, applyAll(e, mod)...)
+	}
+	return ret
+}
+
+func applyDirections(emojis []unidata.Emoji, mod unidata.EmojiModifier) []unidata.Emoji {
+	if mod == 0 {
+		return emojis
+	}
+
+	var ret []unidata.Emoji
+	for _, e := range emojis {
+		if !e.Directions() {
+			ret = append(ret, e)
+			continue
+		}
+		ret = append(ret, applyAll(e, mod)...)
+	}
+	return ret
+}
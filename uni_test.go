@@ -2,18 +2,38 @@ package main
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"zgo.at/uni/v2/unidata"
 	"zgo.at/zli"
 	"zgo.at/zstd/ztest"
 )
 
-func init() {
+func TestMain(m *testing.M) {
 	isTerm = false
+
+	// Don't use the config and history from the user running the tests.
+	tmp, err := os.MkdirTemp("", "uni-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", tmp)
+	os.Setenv("XDG_STATE_HOME", tmp)
+	os.Setenv("XDG_DATA_HOME", tmp)
+
+	c := m.Run()
+	os.RemoveAll(tmp)
+	os.Exit(c)
 }
 
 func TestCLI(t *testing.T) {
@@ -30,6 +50,13 @@ func TestCLI(t *testing.T) {
 		{[]string{"e", "-tone", "xx"}, "invalid skin"},
 		{[]string{"e", "-gender", "xx"}, "invalid gender"},
 		{[]string{"e", "-g", "xxsxxxx"}, `invalid gender: "xxsxxxx"`},
+		{[]string{"e", "-sc", "github,xx"}, `invalid value for -shortcodes: "xx"`},
+		{[]string{"e", "flag:n"}, `invalid region or subdivision code: "n"`},
+		{[]string{"e", "flag:us-ca"}, `no flag for subdivision "us-ca"; supported are: GB-ENG, GB-SCT, GB-WLS`},
+		{[]string{"e", "-hair", "purple"}, `invalid hair style: "purple"`},
+		{[]string{"e", "-dir", "up"}, `invalid direction: "up"`},
+		{[]string{"audit", "-rules", "bidi,xx"}, `invalid value for -rules: "xx"`},
+		{[]string{"i", "-as", "sarif", "x"}, `-as sarif only works for the audit command`},
 	}
 
 	for _, tt := range tests {
@@ -66,6 +93,7 @@ func TestIdentify(t *testing.T) {
 		{[]string{"i", ""}, ""},
 		{[]string{"i", "a"}, "SMALL LETTER A"},
 		{[]string{"i", `"`}, "&quot;"}, // Make sure it uses the lower-case and short variant.
+		{[]string{"i", "-unescape", `\u2042`}, "ASTERISM"},
 
 		{[]string{"i", "\u0600"}, "␣"},                                 // ARABIC NUMBER SIGN
 		{[]string{"i", "\u200d"}, "␣"},                                 // ZERO WIDTH JOINER
@@ -89,6 +117,11 @@ func TestIdentify(t *testing.T) {
 		{[]string{"i", "\U00100000"}, "<Plane 16 Private Use, First>"}, // <Plane 16 Private Use> (First)
 		{[]string{"i", "\U00100001"}, "<Plane 16 Private Use>"},        // <Plane 16 Private Use>
 		{[]string{"i", "\U0010FFFD"}, "<Plane 16 Private Use, Last>"},  // <Plane 16 Private Use> (Last)
+
+		{[]string{"i", "-c", "-f", "%(region)", "🇳🇱"}, "NL\nNL\n"},
+		{[]string{"i", "-c", "-f", "%(region)", "a🏴󠁧󠁢󠁳󠁣󠁴󠁿"}, "\nGB-SCT\nGB-SCT\nGB-SCT\nGB-SCT\nGB-SCT\nGB-SCT\nGB-SCT\n"},
+		{[]string{"i", "-c", "-f", "%(base)|%(tone)|%(gender)", "👍🏿a"}, "👍|dark skin tone|\n👍|dark skin tone|\n||\n"},
+		{[]string{"i", "-c", "-f", "%(base)|%(gender)", "👩‍🚒"}, "🧑‍🚒|woman\n🧑‍🚒|woman\n🧑‍🚒|woman\n"},
 	}
 
 	for _, tt := range tests {
@@ -112,6 +145,39 @@ func TestIdentify(t *testing.T) {
 	}
 }
 
+func TestCommandAlias(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"h"}, "queries the unicode database"},
+		{[]string{"he"}, "queries the unicode database"},
+		{[]string{"e", "-c", "-f", "%(name)", "tada"}, "party popper"},
+		{[]string{"emo", "-c", "-f", "%(name)", "tada"}, "party popper"},
+		{[]string{"s", "-c", "-f", "%(cpoint)", "asterism"}, "U+2042"},
+		{[]string{"p", "-c", "-f", "%(cpoint)", "2042"}, "U+2042"},
+		{[]string{"ls", "scripts"}, "Latin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.in, "_"), func(t *testing.T) {
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.in...)
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+			if *exit != -1 {
+				t.Fatalf("wrong exit: %d\n%s", *exit, out)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("wrong output\nhave: %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
 func TestSearch(t *testing.T) {
 	tests := []struct {
 		in        []string
@@ -189,6 +255,16 @@ func TestPrint(t *testing.T) {
 		{[]string{"p", "xxx..xxx"}, `invalid codepoint: not a number or codepoint: "xxx"`, 1, 1},
 		{[]string{"p", "xxx..xxx"}, `invalid codepoint: not a number or codepoint: "xxx"`, 1, 1},
 
+		{[]string{"-q", "p", `\u2042`}, "ASTERISM", 1, -1},
+		{[]string{"-q", "p", `\N{asterism}`}, "ASTERISM", 1, -1},
+		{[]string{"-q", "p", "%E2%81%82"}, "ASTERISM", 1, -1},
+		{[]string{"-q", "p", "%E2%81%82%E2%81%83"}, "HYPHEN BULLET", 2, -1},
+		{[]string{"-q", "p", "%20AC"}, "EURO SIGN", 1, -1},
+		{[]string{"-q", "p", "%2713"}, "CHECK MARK", 1, -1},
+		{[]string{"-q", "p", "%20"}, "SPACE", 1, -1},
+		{[]string{"-q", "p", "&#x2042;&#x2043;"}, "ASTERISM", 2, -1},
+		{[]string{"p", `\u20`}, `invalid escape: "\\u20"`, 1, 1},
+
 		{[]string{"-q", "p", "U+3402"}, "'㐂'", 1, -1},
 		{[]string{"-q", "p", "U+3402..U+3404"}, "<CJK Ideograph Extension A>", 3, -1},
 		{[]string{"-q", "p", "OtherPunctuation"}, "ASTERISM", 641, -1},
@@ -303,6 +379,41 @@ func TestEmoji(t *testing.T) {
 
 		{[]string{"e", "-qo", "zimbabwe", "#", "england"},
 			[]string{"#S⃣", "🇿🇼", "🏴󠁧󠁢󠁥󠁮󠁧󠁿"}},
+
+		{[]string{"e", "-q", "g:flag", "v:>=16"},
+			[]string{"🇨🇶"}},
+		{[]string{"e", "-q", "g:hands", "v:<3"},
+			[]string{"👏", "🙌", "👐", "🙏"}},
+		{[]string{"e", "-q", "status:component", "skin"},
+			[]string{"🏻", "🏼", "🏽", "🏾", "🏿"}},
+		{[]string{"e", "-q", "status:unq", "n:smiling face"},
+			[]string{"☺"}},
+		{[]string{"e", "-q", "n:smiling face", "g:face-affection"},
+			[]string{"🥰", "😍", "☺S", "🥲"}},
+
+		{[]string{"e", "-q", "sc:tada"},
+			[]string{"🎉"}},
+		{[]string{"e", "-q", "-tone", "dark", "sc::+1:"},
+			[]string{"👍🏿"}},
+		{[]string{"e", "-q", "-sc", "cldr", "sc:party_popper"},
+			[]string{"🎉"}},
+
+		{[]string{"e", "-q", "flag:NL", "flag:gb-sct", "flag:xx"},
+			[]string{"🇳🇱", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "🇽🇽"}},
+		{[]string{"e", "-q", "-hair", "all", "-g", "f", "sc:adult"},
+			[]string{"👩", "👩Z🦰", "👩Z🦱", "👩Z🦳", "👩Z🦲"}},
+		{[]string{"e", "-q", "-hair", "red", "-t", "light", "sc:adult"},
+			[]string{"🧑🏻Z🦰"}},
+		{[]string{"e", "-q", "-dir", "right", "-g", "m", "sc:walking"},
+			[]string{"🚶Z♂SZ➡S"}},
+		{[]string{"e", "-q", "-dir", "all", "sc:walking"},
+			[]string{"🚶", "🚶Z➡S"}},
+		{[]string{"e", "-q", ":)"},
+			[]string{"🙂"}},
+		{[]string{"e", "-q", ";p"},
+			[]string{"😜"}},
+		{[]string{"e", "-q", `¯\_(ツ)_/¯`},
+			[]string{"🤷"}},
 	}
 
 	for _, tt := range tests {
@@ -333,6 +444,947 @@ func TestEmoji(t *testing.T) {
 	}
 }
 
+func TestEmojify(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"emojify", ":tada: done"}, "🎉 done\n"},
+		{[]string{"emojify", ":tada:", ":+1::skin-tone-6:", ":xxx:"}, "🎉 👍🏿 :xxx:\n"},
+		{[]string{"emojify", "-sc", "cldr", ":tada: :party_popper:"}, ":tada: 🎉\n"},
+		{[]string{"emojify", "-emoticons", ":tada: :) <3"}, "🎉 🙂 ❤️\n"},
+		{[]string{"emojify", ":tada: :) <3"}, "🎉 :) <3\n"},
+		{[]string{"demojize", "🎉 done"}, ":tada: done\n"},
+		{[]string{"demojize", "-sc", "cldr", "🎉 👍🏿"}, ":party_popper: :thumbs_up_dark_skin_tone:\n"},
+		{[]string{"unescape", `caf\u00e9 \ud83d\ude00 \xe2\x9c\x93 &#10003;`}, "café 😀 ✓ ✓\n"},
+		{[]string{"unescape", `u"\N{check mark}"`}, "✓\n"},
+		{[]string{"escape", `café "✓"`}, `café \"✓\"` + "\n"},
+		{[]string{"escape", "-ascii", "-lang", "js", "café 😀"}, `caf\u00e9 \u{1f600}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.in, "_"), func(t *testing.T) {
+			_, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.in...)
+
+			main()
+
+			if out.String() != tt.want {
+				t.Errorf("wrong output\nhave: %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		in    string
+		flags []string
+		want  string
+	}{
+		{"\x80\xe9", []string{"-encoding", "cp1252"}, "€ é"},
+		{"\x80\xe9", []string{"-encoding", "latin1"}, "\u0080 é"},
+		{"\xc1", []string{"-encoding", "cp437"}, "┴"},
+		{"\x82\xa0", []string{"-encoding", "shift_jis"}, "あ"},
+		{"\xc4\xe3", []string{"-encoding", "gb18030"}, "你"},
+		{"\xc4\xe1", []string{"-encoding", "koi8-r"}, "д А"},
+		{"a\x00\xe9\x00", []string{"-encoding", "utf-16le"}, "a é"},
+		{"\x00a\x00\xe9", []string{"-encoding", "utf-16be"}, "a é"},
+		{"\xff\xfea\x00\xe9\x00", []string{"-encoding", "utf-16"}, "a é"},
+		{"\x00\x00\x00a", []string{"-encoding", "utf-32"}, "a"},
+
+		// BOM detection.
+		{"\xff\xfea\x00\xe9\x00\n\x00", nil, "a é"},
+		{"\xfe\xff\x00a\x00\xe9\x00\n", nil, "a é"},
+		{"\xff\xfe\x00\x00a\x00\x00\x00", nil, "a"},
+		{"\xef\xbb\xbfa", nil, "\ufeff a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, in, out := zli.Test(t)
+			in.WriteString(tt.in)
+			os.Args = append([]string{"uni", "i", "-q", "-r", "-f", "%(char)"}, tt.flags...)
+
+			main()
+
+			have := strings.Join(strings.Fields(out.String()), " ")
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifyFile(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "file.txt")
+	err := os.WriteFile(tmp, []byte("hello\ncaf\u00e9 \u200bx\n\U0001f44d!"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No newlines, and the flag is split over two reads.
+	long := filepath.Join(t.TempDir(), "long.txt")
+	err = os.WriteFile(long, []byte(strings.Repeat("x", readSize-5)+"\U0001f1f3\U0001f1f1\u00e9"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pct := filepath.Join(t.TempDir(), "b%(name)%(zz).txt")
+	err = os.WriteFile(pct, []byte("aé"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-file", pct, "-nonascii", "-f", "%(cpoint)"}, "" +
+			pct + ":1:2: U+00E9\n"},
+		{[]string{"-file", long, "-nonascii", "-f", "%(cpoint) %(region) %(offset)"}, "" +
+			long + ":1:65532: U+1F1F3 NL 65531\n" +
+			long + ":1:65536: U+1F1F1 NL 65535\n" +
+			long + ":1:65540: U+00E9  65539\n"},
+		{[]string{"-file", tmp, "-nonascii", "-f", "%(cpoint)"}, "" +
+			tmp + ":2:4: U+00E9\n" +
+			tmp + ":2:7: U+200B\n" +
+			tmp + ":3:1: U+1F44D\n"},
+		{[]string{"-file", tmp, "-filter", "cf", "-f", "%(cpoint)"}, "" +
+			tmp + ":2:7: U+200B\n"},
+		{[]string{"-file", tmp, "-nonascii", "-filter", "b:general punctuation,s:latin", "-f", "%(cpoint)"}, "" +
+			tmp + ":2:4: U+00E9\n" +
+			tmp + ":2:7: U+200B\n"},
+		{[]string{"-file", tmp, "-filter", "U+00E9,U+1F000..U+1FFFF", "-f", "%(offset) %(cellcol)"}, "" +
+			tmp + ":2:4: 9 4\n" +
+			tmp + ":3:1: 17 1\n"},
+		{[]string{"-file", "-", "-filter", "U+0021", "-f", "%(cellcol)"}, "" +
+			"(standard input):3:5: 3\n"},
+		{[]string{"-q", "-nonascii", "-f", "%(line):%(col)", "x\u00e9\ny\u00e9"}, "" +
+			"1:2\n" +
+			"2:2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, in, out := zli.Test(t)
+			in.WriteString("hello\ncaf\u00e9 \u200bx\n\U0001f44d!")
+			os.Args = append([]string{"uni", "identify"}, tt.flags...)
+
+			main()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitChunk(t *testing.T) {
+	x := strings.Repeat("x", readSize-6)
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 0},
+		{"a\nb", 2},
+		{"a\nb\n", 4},
+		{x + "abcdef", readSize - 1},
+		{x + "ab\U0001f1f3\U0001f1f1", readSize - 6 + 2},                 // Flag
+		{x + "\U0001f468\u200d\U0001f469\u200d\U0001f467", readSize - 6}, // ZWJ sequence
+		{strings.Repeat("\u0301", readSize/2), readSize},                 // Single grapheme cluster
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if have := splitChunk([]byte(tt.in)); have != tt.want {
+				t.Errorf("have: %d; want: %d", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestMojibake(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"mojibake", "-fix", "cafÃ©"}, "café\n"},
+		{[]string{"mojibake", "-fix", "Itâ€™s Ã¼ber"}, "It’s über\n"},
+		{[]string{"mojibake", "-fix", "ÃƒÂ©tÃƒÂ©"}, "été\n"},
+		{[]string{"mojibake", "-fix", "Ð¿Ñ€Ð¸Ð²ÐµÑ‚"}, "привет\n"},
+		{[]string{"mojibake", "-fix", "caf├⌐"}, "café\n"},
+		{[]string{"mojibake", "-fix", "Ã\u0081lvaro dijo â€œhola"}, "Álvaro dijo “hola\n"},
+		{[]string{"mojibake", "-fix", "Ã\u0081 Ã\u008d Ã\u008f Ã\u0090 Ã\u009d â€“"}, "Á Í Ï Ð Ý –\n"},
+		{[]string{"mojibake", "-fix", "Señor Müller"}, "Señor Müller\n"},
+
+		{[]string{"mojibake", "Itâ€™s"}, "" +
+			"UTF-8 decoded as cp1252 (confidence: 80%)\n\n" +
+			"    Itâ€™s\n" +
+			"    It’s\n\n" +
+			"    â€™ (U+00E2 U+20AC U+2122) → ’ U+2019 RIGHT SINGLE QUOTATION MARK\n"},
+		{[]string{"mojibake", "ÃƒÂ©tÃƒÂ©"}, "" +
+			"UTF-8 decoded as cp1252, and then as cp1252 (confidence: 100%)\n\n" +
+			"    ÃƒÂ©tÃƒÂ©\n" +
+			"    été\n\n" +
+			"    ÃƒÂ© (U+00C3 U+0192 U+00C2 U+00A9) → é U+00E9 LATIN SMALL LETTER E WITH ACUTE\n"},
+		{[]string{"mojibake", "Ã\u0081lvaro dijo â€œhola"}, "" +
+			"UTF-8 decoded as cp1252 (confidence: 100%)\n\n" +
+			"    Ã\u0081lvaro dijo â€œhola\n" +
+			"    Álvaro dijo “hola\n\n" +
+			"    Ã\u0081 (U+00C3 U+0081)         → Á U+00C1 LATIN CAPITAL LETTER A WITH ACUTE\n" +
+			"    â€œ (U+00E2 U+20AC U+0153) → “ U+201C LEFT DOUBLE QUOTATION MARK\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.in, "_"), func(t *testing.T) {
+			_, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.in...)
+
+			main()
+
+			if out.String() != tt.want {
+				t.Errorf("wrong output\nhave: %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	tests := []struct {
+		in      string
+		invalid bool
+		want    string
+	}{
+		{"a\u20ac", false, "" +
+			"00000000  61           a  U+0061 LATIN SMALL LETTER A\n" +
+			"00000001  e2 82 ac     €  U+20AC EURO SIGN\n"},
+		{"\x80", true, "00000000  80           invalid: unexpected continuation byte\n"},
+		{"\xff", true, "00000000  ff           invalid: byte 0xff never appears in UTF-8\n"},
+		{"\xe2\x82x", true, "" +
+			"00000000  e2 82        invalid: truncated sequence: expected 3 bytes, got 2\n" +
+			"00000002  78           x  U+0078 LATIN SMALL LETTER X\n"},
+		{"\xc0\xaf", true, "00000000  c0 af        invalid: overlong encoding of U+002F\n"},
+		{"\xe0\x80\xaf", true, "00000000  e0 80 af     invalid: overlong encoding of U+002F\n"},
+		{"\xf4\x90\x80\x80", true, "00000000  f4 90 80 80  invalid: out of range: U+110000 is above U+10FFFF\n"},
+		{"\xed\xa0\x80", true, "00000000  ed a0 80     invalid: surrogate U+D800 encoded in UTF-8 (WTF-8)\n"},
+		{"\xed\xa0\xbd\xed\xb8\x80", true, "" +
+			"00000000  ed a0 bd ed b8 80  invalid: surrogate pair U+D83D U+DE00 encoded in UTF-8 (CESU-8) for U+1F600\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.in), func(t *testing.T) {
+			exit, in, out := zli.Test(t)
+			in.WriteString(tt.in)
+			os.Args = []string{"uni", "hexdump"}
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			have, _, _ := strings.Cut(out.String(), "uni: ")
+			if have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+			if (*exit == 1) != tt.invalid {
+				t.Errorf("wrong exit: %d", *exit)
+			}
+		})
+	}
+}
+
+func TestReveal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"no secrets", "uni: no matches\n"},
+		// Flag of Scotland and an emoji with VS16.
+		{"\U0001f3f4\U000e0067\U000e0062\U000e0073\U000e0063\U000e0074\U000e007f \u2764\ufe0f", "uni: no matches\n"},
+		{"a\U000e0068\U000e0069\U000e007f b",
+			`1:2: tags, 3 codepoints: "hi"` + "\n"},
+		{"x\ufe0e\U000e0135\U000e0139",
+			`1:2: variation selectors, 3 codepoints: "\x0eEI"` + "\n"},
+		{"\u2764\ufe0f\U000e0170\U000e01ef",
+			"1:4: variation selectors, 3 codepoints: 0f 80 ff\n"},
+		{"x\u200b\u200c\u200c\u200b\u200c\u200c\u200c\u200c\u200b\u200c\u200c\u200b\u200c\u200b\u200c\u200c",
+			`1:2: zero-width binary (U+200B=0 U+200C=1), 16 codepoints: "ok"` + "\n"},
+		{"\u200c\u200c\u200b\u200c\u200b\u200b\u200b\u200d\u200c\u200c\u200b\u200c\u200b\u200b\u200c",
+			`1:1: zero-width binary (U+200B=0 U+200C=1 U+200D=separator), 15 codepoints: "hi"` + "\n"},
+		{"a\u200b\u200b\u200b\u200b\u200b\u200b\u200b\u200b\u200b",
+			"1:2: zero-width binary, 9 codepoints: could not decode\n"},
+		{"a\nb \U000e0061\nc",
+			`2:3: tags, 1 codepoints: "a"` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.in), func(t *testing.T) {
+			exit, _, out := zli.Test(t)
+			os.Args = []string{"uni", "reveal", tt.in}
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		in    string
+		flags []string
+		want  string
+	}{
+		{"", nil, "uni: no matches\n"},
+		{"ab\u0431!", []string{"-by", "script"}, "" +
+			"Count  Percent  Name\n" +
+			"    2    50.0%  Latin\n" +
+			"    1    25.0%  Common\n" +
+			"    1    25.0%  Cyrillic\n"},
+		{"a\u00e9a", []string{"-by", "u,b", "-c"}, "" +
+			"Unicode:\n" +
+			"3  100.0%  1.1\n" +
+			"\n" +
+			"Block:\n" +
+			"2  66.7%  Basic Latin\n" +
+			"1  33.3%  Latin-1 Supplement\n"},
+		{"aab", []string{"-by", "codepoint", "-f", "%(count) %(cpoint)"}, "" +
+			"Count CPoint\n" +
+			"2 U+0061\n" +
+			"1 U+0062\n"},
+		{"aab", []string{"-by", "codepoint,script", "-f", "%(count) %(cpoint)"},
+			`uni: -format flag: unknown placeholder: "cpoint"; only %(count), %(percent), and %(name) work with -by script` + "\n"},
+		{"ab", []string{"-by", "cat", "-as", "json", "-c"},
+			`[{"count":"2","name":"Lowercase_Letter","percent":"100.00"}]` + "\n"},
+		{"ab", []string{"-by", "cat,u", "-as", "json", "-c"}, "" +
+			`{"category": [{"count":"2","name":"Lowercase_Letter","percent":"100.00"}],` + "\n" +
+			`"unicode": [{"count":"2","name":"1.1","percent":"100.00"}]}` + "\n"},
+		{"a", []string{"-by", "c"}, `uni: -by: ambigious command: "c"; matches: "category", "codepoint"` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			exit, in, out := zli.Test(t)
+			in.WriteString(tt.in)
+			os.Args = append([]string{"uni", "stats"}, tt.flags...)
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	for _, flags := range [][]string{{"-as", "json"}, {"-as", "json", "-c"}} {
+		t.Run("", func(t *testing.T) {
+			_, in, out := zli.Test(t)
+			in.WriteString("ab\u0431!")
+			os.Args = append([]string{"uni", "stats"}, flags...)
+			main()
+
+			var have map[string][]map[string]string
+			if err := json.Unmarshal(out.Bytes(), &have); err != nil {
+				t.Fatalf("%s\n%s", err, out)
+			}
+			if len(have) != 5 {
+				t.Errorf("wrong number of groups: %d", len(have))
+			}
+			want := map[string]string{"count": "2", "name": "Latin", "percent": "50.00"}
+			if len(have["script"]) != 3 || !reflect.DeepEqual(have["script"][0], want) {
+				t.Errorf("\nhave: %v\nwant: %v", have["script"], want)
+			}
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		in    string
+		flags []string
+		want  string
+	}{
+		{"abc\n\u65e5\u672c", nil, "" +
+			"Runewidth  Uniseg  EAW   Text\n" +
+			"        3       3    3  'abc'\n" +
+			"        4       4    4  '\u65e5\u672c'\n"},
+		{"abc\n\u2764\ufe0f", []string{"-diff"}, "" +
+			"Runewidth  Uniseg  EAW   Text\n" +
+			"        2       2    1  '\u2764\ufe0f'\n"},
+		{"a\U0001f468\u200d\U0001f469", []string{"-diff", "-by", "grapheme", "-c"},
+			"4  2  4  '\U0001f468\u200d\U0001f469'  U+1F468 U+200D U+1F469\n"},
+		{"e\u0301", []string{"-by", "grapheme", "-f", "%(runewidth) %(uniseg) %(eaw) %(cpoints)", "-c"},
+			"1 1 1 U+0065 U+0301\n"},
+		{"abc", []string{"-diff"}, "uni: no matches\n"},
+		{"abc", []string{"-by", "word"}, `uni: -by: must be line or grapheme for width, not "word"` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			exit, in, out := zli.Test(t)
+			in.WriteString(tt.in)
+			os.Args = append([]string{"uni", "width"}, tt.flags...)
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguous(t *testing.T) {
+	tests := []struct {
+		flags []string
+		env   string
+		want  string
+	}{
+		{[]string{"-ambiguous", "narrow"}, "", "" +
+			"'\u00b1' |1|ambiguous\n" +
+			"'\u65e5'|2|wide\n"},
+		{[]string{"-ambiguous", "wide"}, "", "" +
+			"'\u00b1'|2|ambiguous\n" +
+			"'\u65e5'|2|wide\n"},
+		{nil, "wide", "" +
+			"'\u00b1'|2|ambiguous\n" +
+			"'\u65e5'|2|wide\n"},
+		{[]string{"-ambiguous", "n"}, "wide", "" +
+			"'\u00b1' |1|ambiguous\n" +
+			"'\u65e5'|2|wide\n"},
+		{[]string{"-ambiguous", "x"}, "", `uni: invalid value for -ambiguous: "x"` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			t.Setenv("UNI_AMBIGUOUS", tt.env)
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni", "i", "\u00b1\u65e5", "-c", "-f", "%(char q)%(wide_padding)|%(cells)|%(width)"}, tt.flags...)
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignWidth(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-ambiguous", "wide", "-f", "%(char l:auto)|", "i", "a\u00b1"}, "" +
+			"a |\n" +
+			"\u00b1|\n"},
+		{[]string{"-ambiguous", "narrow", "-f", "%(char l:auto)|", "i", "a\u00b1"}, "" +
+			"a|\n" +
+			"\u00b1|\n"},
+		{[]string{"-f", "%(char r:auto)|%(char l:3)|", "i", "a\U0001f600"}, "" +
+			" a|a  |\n" +
+			"\U0001f600|\U0001f600 |\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			t.Setenv("UNI_AMBIGUOUS", "")
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni", "-c"}, tt.flags...)
+
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		args    []string
+		keys    string
+		want    string
+		wantErr error
+	}{
+		{nil, "euro sign\r", "€", nil},
+		{nil, "euro\x17latin small letter sharp s\r", "ß", nil},
+		{nil, "latin capital letter a with ring\t\x1b[B\t\r", "ÅǺ", nil},
+		{nil, "\x05thumbs up\r", "👍", nil},
+		{[]string{"emoji", "waving", "hand"}, "\x14\x14\r", "👋🏼", nil},
+		{[]string{"emoji", "person", "bowing"}, "\x07\x07\r", "🙇‍♀️", nil},
+		{[]string{"emoji", "waving", "hand"}, "\x19", "👋", nil},
+		{nil, "euro sign\x1b", "", errCanceled},
+		{nil, "euro sign", "", errCanceled},
+		{nil, "\r\x1b", "", errCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.keys), func(t *testing.T) {
+			p := newPicker(tt.args, 0, unidata.ModPerson, nil, false)
+			sel, _, err := runPicker(p, strings.NewReader(tt.keys), io.Discard, func() (int, int) { return 80, 24 })
+			if err != tt.wantErr {
+				t.Fatalf("wrong error\nhave: %v\nwant: %v", err, tt.wantErr)
+			}
+			if have := strings.Join(sel, ""); have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyFork(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+
+	// wl-copy and xclip keep running in the background.
+	dir := t.TempDir()
+	out := filepath.Join(dir, "clipboard")
+	err := os.WriteFile(filepath.Join(dir, "wl-copy"),
+		[]byte("#!/bin/sh\ncat >"+out+"\nsleep 5 &\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	t.Setenv("DISPLAY", "")
+	ttyPath = filepath.Join(dir, "nonexistent")
+	defer func() { ttyPath = "/dev/tty" }()
+
+	start := time.Now()
+	if err := copyToClipboard("\u20ac"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("took %s", d)
+	}
+	if have, _ := os.ReadFile(out); string(have) != "\u20ac" {
+		t.Errorf("have: %q", have)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-copy", "s", "euro", "sign"}, "₠€💶"},
+		{[]string{"-copy", "-as", "json", "p", "U+41..U+43"}, "ABC"},
+		{[]string{"-copy", "-as", "table", "p", "U+41..U+43"}, "ABC"},
+		{[]string{"-copy", "i", "a\u0301"}, "a\u0301"},
+		{[]string{"-copy", "e", "-tone", "dark", "waving hand"}, "👋🏿"},
+		{[]string{"-copy-format", "cpoint", "s", "euro", "sign"}, "U+20A0\nU+20AC\nU+1F4B6"},
+		{[]string{"-copy-format", "%(dec): %(name)", "p", "U+41"}, "65: LATIN CAPITAL LETTER A"},
+		{[]string{"-copy", "emojify", ":tada:"}, "🎉"},
+		{[]string{"-copy", "unescape", `\u20ac`}, "€"},
+		{[]string{"-copy-format", "nope", "p", "U+41"}, `uni: -copy-format flag: unknown placeholder: "nope"`},
+		{[]string{"-copy-format", "%(cpoint) %(nope)", "p", "U+41"}, `uni: -copy-format flag: unknown placeholder: "nope"`},
+		{[]string{"-copy-format", "aliases", "p", "U+41"}, ""},
+	}
+
+	t.Setenv("PATH", "")
+	t.Setenv("TMUX", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	defer func() { ttyPath = "/dev/tty" }()
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			ttyPath = filepath.Join(t.TempDir(), "tty")
+			os.WriteFile(ttyPath, nil, 0o644)
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			osc, _ := os.ReadFile(ttyPath)
+			if tt.want == "" || strings.HasPrefix(tt.want, "uni: ") {
+				want := tt.want
+				if want == "" {
+					want = "nothing to copy"
+				}
+				if len(osc) != 0 || *exit != 1 || !strings.Contains(out.String(), want) {
+					t.Errorf("exit %d; tty: %q\n%s", *exit, osc, out)
+				}
+				return
+			}
+			if *exit != -1 {
+				t.Fatalf("exit %d: %s", *exit, out)
+			}
+			have, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(string(osc), "\x1b]52;c;"), "\x07"))
+			if err != nil {
+				t.Fatalf("%q: %s", osc, err)
+			}
+			if string(have) != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	f := []string{"-c", "-f", "%(char) %(cpoint) %(name)"}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{append([]string{"-copy", "s", "euro", "sign"}, f...), "" +
+			"₠ U+20A0 EURO-CURRENCY SIGN\n" +
+			"€ U+20AC EURO SIGN\n" +
+			"💶 U+1F4B6 BANKNOTE WITH EURO SIGN\n"},
+		{[]string{"-c", "-copy", "-f", "%(emoji) %(name)", "e", "-tone", "dark", "waving hand"}, "👋🏿 waving hand: dark skin tone\n"},
+		{[]string{"-c", "-copy", "-f", "%(emoji) %(name)", "e", "black flag"}, "🏴 black flag\n"},
+		{append([]string{"recent"}, f...), "" +
+			"🏴 U+1F3F4 WAVING BLACK FLAG\n" +
+			"👋🏿 U+1F44B U+1F3FF waving hand: dark skin tone\n" +
+			"💶 U+1F4B6 BANKNOTE WITH EURO SIGN\n" +
+			"€ U+20AC EURO SIGN\n" +
+			"₠ U+20A0 EURO-CURRENCY SIGN\n"},
+		{append([]string{"recent", "2"}, f...), "" +
+			"🏴 U+1F3F4 WAVING BLACK FLAG\n" +
+			"👋🏿 U+1F44B U+1F3FF waving hand: dark skin tone\n"},
+		{[]string{"recent", "0"}, `uni: recent: not a positive number: "0"` + "\n"},
+		{append([]string{"s", "euro", "sign"}, f...), "" +
+			"💶 U+1F4B6 BANKNOTE WITH EURO SIGN\n" +
+			"€ U+20AC EURO SIGN\n" +
+			"₠ U+20A0 EURO-CURRENCY SIGN\n"},
+		{[]string{"-c", "-f", "%(emoji) %(name)", "e", "waving"}, "" +
+			"🏴 black flag\n" +
+			"👋 waving hand\n" +
+			"🏳️ white flag\n"},
+		{[]string{"fav"}, "uni: no matches\n"},
+		{[]string{"fav", "add", "€👍🏽", "a b"}, ""},
+		{append([]string{"fav"}, f...), "" +
+			"€ U+20AC EURO SIGN\n" +
+			"👍🏽 U+1F44D U+1F3FD thumbs up: medium skin tone\n" +
+			"a U+0061 LATIN SMALL LETTER A\n" +
+			"b U+0062 LATIN SMALL LETTER B\n"},
+		{[]string{"fav", "rm", "ab"}, ""},
+		{[]string{"-c", "-f", "%(char)", "fav", "l"}, "€\n👍🏽\n"},
+		{[]string{"fav", "rm", "x"}, `uni: fav rm: not a favourite: "x"` + "\n"},
+		{[]string{"fav", "nope"}, `uni: fav: unknown command: "nope"` + "\n"},
+	}
+
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("PATH", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	ttyPath = filepath.Join(t.TempDir(), "tty")
+	os.WriteFile(ttyPath, nil, 0o644)
+	defer func() { ttyPath = "/dev/tty" }()
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		config string
+		args   []string
+		want   string
+	}{
+		{"format = %(char) %(name)", []string{"-c", "p", "U+41"}, "A LATIN CAPITAL LETTER A\n"},
+		{"format = %(char) %(name)", []string{"-c", "-f", "%(cpoint)", "p", "U+41"}, "U+0041\n"},
+		{"format = %(char) %(name)", []string{"-c", "width", "a"}, "1  1  1  'a'\n"},
+		{"format = %(char) %(name)\n[print]\nformat = %(dec)", []string{"-c", "p", "U+41"}, "65\n"},
+		{"tone = dark\n\n[emoji]\nformat = %(emoji) %(name)", []string{"-c", "e", "waving hand"},
+			"👋🏿 waving hand: dark skin tone\n"},
+		{"tone = dark\n\n[emoji]\nformat = %(emoji) %(name)", []string{"-c", "-tone", "light", "e", "waving hand"},
+			"👋🏻 waving hand: light skin tone\n"},
+		{"[search]\nor = true", []string{"-c", "-f", "%(char)", "s", "asterism", "euro-currency"}, "⁂\n₠\n"},
+		{"[print]\nor = true", []string{"-f", "%(char)", "s", "asterism", "euro-currency"}, "uni: no matches\n"},
+
+		{"# Comment\n[aliases]\nab = U+41 U+42", []string{"-c", "-f", "%(char)", "p", "@ab", "U+43"}, "A\nB\nC\n"},
+		{"[aliases]\nast = asterism", []string{"-c", "-f", "%(char)", "s", "@ast"}, "⁂\n"},
+		{"[aliases]\nast = asterism", []string{"-c", "-f", "%(char)", "s", "@nope"}, "uni: no alias \"@nope\" in {config}\n"},
+		{"[formats]\nmine = %(char):%(dec)", []string{"-c", "-f", "@mine", "p", "U+41"}, "A:65\n"},
+		{"format = @mine\n[formats]\nmine = %(char):%(dec)", []string{"-c", "p", "U+41"}, "A:65\n"},
+		{"[formats]\nmine = %(char):%(dec)", []string{"-c", "-f", "@nope", "p", "U+41"}, "uni: -format flag: no preset \"@nope\" in {config}\n"},
+
+		{"nope = 1", []string{"p", "U+41"}, "uni: {config}:1: unknown key: \"nope\"\n"},
+		{"\n[nope]", []string{"p", "U+41"}, "uni: {config}:2: unknown section: \"nope\"\n"},
+		{"[print", []string{"p", "U+41"}, "uni: {config}:1: no ] in section name: \"[print\"\n"},
+		{"print", []string{"p", "U+41"}, "uni: {config}:1: not in the form of key = value: \"print\"\n"},
+		{"or = maybe", []string{"p", "U+41"}, "uni: {config}:1: or must be true or false, not \"maybe\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+			path := filepath.Join(dir, "uni", "config")
+			os.MkdirAll(filepath.Dir(path), 0o755)
+			os.WriteFile(path, []byte(tt.config), 0o644)
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			want := strings.ReplaceAll(tt.want, "{config}", path)
+			if have := out.String(); have != want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	annotations := "" +
+		"# Team vocabulary.\n" +
+		"U+2713 = tick, donezo\n" +
+		"🚢     = ship it, shipit, deploy\n" +
+		"👍🏽     = LGTM\n" +
+		"=      = eqsign\n"
+
+	tests := []struct {
+		annotations string
+		args        []string
+		want        string
+	}{
+		{annotations, []string{"-c", "-f", "%(char) %(name) [%(aliases)]", "s", "donezo"}, "✓ CHECK MARK [tick, donezo]\n"},
+		{annotations, []string{"-c", "-f", "%(char) %(name) [%(aliases)]", "s", "shipit"}, "🚢 SHIP [cruise line vacation, ship it, shipit, deploy]\n"},
+		{annotations, []string{"-c", "-f", "%(char) %(name) [%(aliases)]", "s", "eqsign"}, "= EQUALS SIGN [eqsign]\n"},
+		{annotations, []string{"-c", "-f", "%(char) [%(aliases)]", "p", "U+2713"}, "✓ [tick, donezo]\n"},
+		{annotations, []string{"-c", "-f", "%(emoji) %(name) [%(cldr)]", "e", "shipit"}, "🚢 ship [boat, passenger, travel, ship it, shipit, deploy]\n"},
+		{annotations, []string{"-c", "-f", "%(emoji) %(name) [%(cldr)]", "e", "-tone", "dark", "lgtm"},
+			"👍🏿 thumbs up: dark skin tone [+1, good, hand, like, yes, lgtm]\n"},
+
+		{"\nU+41", []string{"p", "U+41"}, "uni: {path}:2: not in the form of codepoint = keywords: \"U+41\"\n"},
+		{"U+41 = ,", []string{"p", "U+41"}, "uni: {path}:1: no keywords for \"U+41\"\n"},
+		{"xyz = x", []string{"p", "U+41"}, "uni: {path}:1: not a codepoint or emoji: \"xyz\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+			path := filepath.Join(dir, "uni", "annotations")
+			os.MkdirAll(filepath.Dir(path), 0o755)
+			os.WriteFile(path, []byte(tt.annotations), 0o644)
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			want := strings.ReplaceAll(tt.want, "{path}", path)
+			if have := out.String(); have != want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+			}
+		})
+	}
+}
+
+func TestPUA(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Cleanup(func() {
+		puaNames = nil
+		unidata.SetPUANames(nil)
+	})
+
+	glyphs := filepath.Join(dir, "glyphnames.json")
+	os.WriteFile(glyphs, []byte(`{
+		"METADATA":       {"website": "https://nerdfonts.com", "version": "3.2.1"},
+		"dev-git_branch": {"char": "\ue725", "code": "e725"},
+		"pl-branch":      {"char": "\ue0a0", "code": "e0a0"},
+		"md-ab_testing":  {"char": "\udb80\udc01", "code": "f0001"},
+		"not-pua":        {"char": "A", "code": "41"}
+	}`), 0o644)
+	table := filepath.Join(dir, "mine.txt")
+	os.WriteFile(table, []byte("# Comment\nU+E725 my-branch\nE000 first\n"), 0o644)
+	bad := filepath.Join(dir, "bad.txt")
+	os.WriteFile(bad, []byte("U+E000 ok\nU+41 letter\n"), 0o644)
+
+	path := filepath.Join(dir, "uni", "pua", "nerdfonts.txt")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"pua", "import", "nerdfonts", glyphs}, "imported 3 names to " + path + "; use with \"-pua nerdfonts\"\n"},
+		{[]string{"pua", "list"}, "nerdfonts             3 names  " + path + "\n"},
+		{[]string{"-c", "-f", "%(cpoint) %(name)", "-pua", "nerdfonts", "i", "\ue725\U000f0001"},
+			"U+E725 nf-dev-git_branch\nU+F0001 nf-md-ab_testing\n"},
+		{[]string{"-c", "-f", "%(cpoint) %(name)", "-pua", "nerdfonts", "s", "git_branch"}, "U+E725 nf-dev-git_branch\n"},
+		{[]string{"-c", "-f", "%(cpoint) %(name)", "-pua", "nerdfonts", "p", "U+E0A0..U+E0A1"},
+			"U+E0A0 nf-pl-branch\nU+E0A1 <Private Use>\n"},
+		{[]string{"-c", "-f", "%(cpoint) %(name)", "-pua", table + ",nerdfonts", "p", "U+E000", "U+E725", "U+E0A0"},
+			"U+E000 first\nU+E0A0 nf-pl-branch\nU+E725 my-branch\n"},
+		{[]string{"-c", "-f", "%(cpoint) %(name)", "p", "U+E725"}, "U+E725 <Private Use>\n"},
+
+		{[]string{"-pua", "nope", "p", "U+E725"},
+			"uni: -pua flag: no table \"nope\" in " + filepath.Dir(path) + "; add one with \"uni pua import\"\n"},
+		{[]string{"-pua", bad, "p", "U+E725"}, "uni: -pua flag: " + bad + ":2: U+0041 is not in a Private Use Area\n"},
+		{[]string{"pua", "import", "x", glyphs}, "uni: pua import: unknown format \"x\"; formats: codepoints, fontawesome, nerdfonts, unicodedata\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			puaNames = nil
+			unidata.SetPUANames(nil)
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni"}, tt.args...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			if have := out.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	files := map[string]string{
+		"a.go": "" +
+			"access = \"user\u202e \u2066// admin\u2069\u2066\"\n" +
+			"p\u0430ypal := 1\n" +
+			"zero\u200bwidth \U0001f468\u200d\U0001f469 caf\u00e9 cafe\u0301\n" +
+			"bell\x07 \u65e5\u672c\u8a9e\u30c6\u30ad\u30b9\u30c8 \u043f\u0440\u0438\u0432\u0435\u0442\n",
+		"zwj/c.txt":  "\U0001f468\u200d\u0301\u0323\n",
+		"sub/b.md":   "\ufeffOK \u201cquoted\u201d\n",
+		".git/x":     "\u202e",
+		"binary.bin": "\x00\u202e",
+	}
+
+	tests := []struct {
+		flags    []string
+		allow    string
+		want     string
+		wantFile string
+	}{
+		{nil, "", "" +
+			"a.go:1:15: bidi: U+202E RIGHT-TO-LEFT OVERRIDE\n" +
+			"a.go:1:19: bidi: U+2066 LEFT-TO-RIGHT ISOLATE\n" +
+			"a.go:1:30: bidi: U+2069 POP DIRECTIONAL ISOLATE\n" +
+			"a.go:1:33: bidi: U+2066 LEFT-TO-RIGHT ISOLATE\n" +
+			"a.go:2:2: mixed-script: \"p\u0430ypal\" mixes Latin and Cyrillic\n" +
+			"a.go:2:2: confusable: U+0430 CYRILLIC SMALL LETTER A looks like \"a\" in \"p\u0430ypal\"\n" +
+			"a.go:3:5: invisible: U+200B ZERO WIDTH SPACE\n" +
+			"a.go:3:35: nfc: e\u0301 (U+0065 U+0301) is \u00e9 (U+00E9) in NFC\n" +
+			"a.go:4:5: control: U+0007 BELL\n" +
+			"zwj/c.txt:1:5: nfc: \u200d\u0301\u0323 (U+200D U+0301 U+0323) is \u200d\u0323\u0301 (U+200D U+0323 U+0301) in NFC\n", ""},
+
+		{[]string{"-rules", "nfc,control"}, "", "" +
+			"a.go:3:35: nfc: e\u0301 (U+0065 U+0301) is \u00e9 (U+00E9) in NFC\n" +
+			"a.go:4:5: control: U+0007 BELL\n" +
+			"zwj/c.txt:1:5: nfc: \u200d\u0301\u0323 (U+200D U+0301 U+0323) is \u200d\u0323\u0301 (U+200D U+0323 U+0301) in NFC\n", ""},
+		{[]string{"-rules=-bidi,-confusable,-mixed-script"}, "*.go U+200B\na.go nfc\n", "" +
+			"a.go:4:5: control: U+0007 BELL\n" +
+			"zwj/c.txt:1:5: nfc: \u200d\u0301\u0323 (U+200D U+0301 U+0323) is \u200d\u0323\u0301 (U+200D U+0323 U+0301) in NFC\n", ""},
+		// Not U+0ADD and U+0BAD, but globs.
+		{[]string{"-rules", "invisible", "a.go"}, "add invisible\nbad U+200B\n", "" +
+			"a.go:3:5: invisible: U+200B ZERO WIDTH SPACE\n", ""},
+
+		// NFC segment that starts after the ZWJ, in the middle of a cluster.
+		{[]string{"-rules", "nfc", "zwj"}, "", "" +
+			"zwj/c.txt:1:5: nfc: \u200d\u0301\u0323 (U+200D U+0301 U+0323) is \u200d\u0323\u0301 (U+200D U+0323 U+0301) in NFC\n", ""},
+
+		{[]string{"-fix", "-rules", "bidi,invisible"}, "", "" +
+			"a.go:1:15: bidi: U+202E RIGHT-TO-LEFT OVERRIDE (fixed)\n" +
+			"a.go:1:19: bidi: U+2066 LEFT-TO-RIGHT ISOLATE (fixed)\n" +
+			"a.go:1:30: bidi: U+2069 POP DIRECTIONAL ISOLATE (fixed)\n" +
+			"a.go:1:33: bidi: U+2066 LEFT-TO-RIGHT ISOLATE (fixed)\n" +
+			"a.go:3:5: invisible: U+200B ZERO WIDTH SPACE (fixed)\n",
+			"access = \"user // admin\"\n"},
+
+		{[]string{"-j", "-rules", "control"}, "", `[
+	{
+		"path": "a.go",
+		"line": 4,
+		"col": 5,
+		"offset": 92,
+		"rule": "control",
+		"cpoint": "U+0007",
+		"message": "U+0007 BELL",
+		"fixed": false
+	}
+]
+`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.flags, " "), func(t *testing.T) {
+			tmp := t.TempDir()
+			for name, data := range files {
+				os.MkdirAll(filepath.Join(tmp, filepath.Dir(name)), 0o755)
+				err := os.WriteFile(filepath.Join(tmp, name), []byte(data), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.allow != "" {
+				err := os.WriteFile(filepath.Join(tmp, ".allow"), []byte(tt.allow), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.flags = append(tt.flags, "-allow", ".allow")
+			}
+			t.Chdir(tmp)
+
+			exit, _, out := zli.Test(t)
+			os.Args = append([]string{"uni", "audit"}, tt.flags...)
+			func() {
+				defer exit.Recover()
+				main()
+			}()
+
+			have, _, _ := strings.Cut(out.String(), "uni: ")
+			if have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+			if tt.wantFile != "" {
+				f, _ := os.ReadFile("a.go")
+				if have, _, _ := strings.Cut(string(f), "\n"); have+"\n" != tt.wantFile {
+					t.Errorf("\nhave: %q\nwant: %q", have, tt.wantFile)
+				}
+			}
+		})
+	}
+}
+
 func TestFormat(t *testing.T) {
 	tests := []struct {
 		in   []string
@@ -386,27 +1438,48 @@ func TestJSON(t *testing.T) {
 
 	want := ` [{
 	"aliases": "",
+	"base":    "",
 	"bin":     "10000010101100",
 	"block":   "Currency Symbols",
+	"c":       "\\u20ac",
 	"cat":     "Currency_Symbol",
+	"cellcol": "1",
 	"cells":   "1",
 	"char":    "€",
+	"col":     "1",
+	"cp1252":  "80",
 	"cpoint":  "U+20AC",
+	"css":     "\\20ac",
 	"dec":     "8364",
 	"digraph": "=e",
+	"gender":  "",
+	"go":      "\\u20ac",
 	"hex":     "20ac",
 	"html":    "&euro;",
+	"java":    "\\u20ac",
+	"js":      "\\u20ac",
 	"json":    "\\u20ac",
 	"keysym":  "EuroSign",
+	"latin1":  "",
+	"line":    "1",
 	"name":    "EURO SIGN",
 	"oct":     "20254",
+	"offset":  "0",
 	"plane":   "Basic Multilingual Plane",
 	"props":   "",
+	"python":  "\\u20ac",
 	"refs":    "U+20A0",
+	"region":  "",
+	"rust":    "\\u{20ac}",
 	"script":  "Common",
+	"sjis":    "",
+	"sql":     "\\20ac",
+	"tone":    "",
 	"unicode": "2.1",
+	"url":     "%E2%82%AC",
 	"utf16be": "20 ac",
 	"utf16le": "ac 20",
+	"utf32":   "00 00 20 ac",
 	"utf8":    "e2 82 ac",
 	"width":   "ambiguous",
 	"xml":     "&#x20ac;"
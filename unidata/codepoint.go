@@ -179,6 +179,9 @@ func FindProperty(name string) (Property, bool) {
 func Find(cp rune) (Codepoint, bool) {
 	info, ok := Codepoints[cp]
 	if ok {
+		if n, ok := puaNames[cp]; ok {
+			info.name = n
+		}
 		return info, true
 	}
 
@@ -192,6 +195,9 @@ func Find(cp rune) (Codepoint, bool) {
 
 			info.Codepoint = cp
 			info.name = r.name
+			if n, ok := puaNames[cp]; ok {
+				info.name = n
+			}
 			return info, true
 		}
 	}
@@ -286,8 +292,49 @@ func (c Codepoint) Name() string { return c.name }
 func (c Codepoint) Width() Width { return c.width }
 
 // Cells gets the number of cells this codepoint will display as; 0, 1, or 2.
+//
+// Characters with an ambiguous width are 1 cell, unless SetAmbiguousWide() was
+// used or the locale is CJK.
 func (c Codepoint) Cells() uint8 { return uint8(runewidth.RuneWidth(c.Codepoint)) }
 
+// IsWide reports if this codepoint is a wide or fullwidth character, or an
+// ambiguous one if ambiguous characters are wide.
+func (c Codepoint) IsWide() bool {
+	return c.width == WidthWide || c.width == WidthFullWidth ||
+		(c.width == WidthAmbiguous && runewidth.DefaultCondition.EastAsianWidth)
+}
+
+// SetAmbiguousWide sets if characters with an ambiguous East Asian Width are
+// displayed as 2 cells, as they are in most CJK terminals. The default is to
+// detect this from the locale.
+//
+// This sets the width for the entire program, including other packages that
+// use zgo.at/runewidth.
+func SetAmbiguousWide(wide bool) {
+	runewidth.EastAsianWidth = wide
+	runewidth.DefaultCondition.EastAsianWidth = wide
+}
+
+// Names for codepoints in the Private Use Areas; see SetPUANames().
+var puaNames map[rune]string
+
+// SetPUANames sets the names for codepoints in the Private Use Areas, which
+// are otherwise all named "<Private Use>". This is useful for icon fonts such
+// as Nerd Fonts or Font Awesome, which use the same codepoints for the same
+// icons.
+//
+// Find() will return these names. It's an error if one of the codepoints isn't
+// in a Private Use Area.
+func SetPUANames(names map[rune]string) error {
+	for cp := range names {
+		if info, _ := Find(cp); info.Category() != CatPrivateUse {
+			return fmt.Errorf("unidata.SetPUANames: U+%04X is not in a Private Use Area", cp)
+		}
+	}
+	puaNames = names
+	return nil
+}
+
 // Category gets this codepoint's category.
 func (c Codepoint) Category() Category { return c.category }
 
@@ -357,6 +404,16 @@ func (c Codepoint) Refs() []string {
 	return s
 }
 
+// Confusable gets the ASCII text this codepoint can be confused with, or ""
+// if there is none; for example "a" for U+0430 (CYRILLIC SMALL LETTER A).
+//
+// This uses the "skeleton" from Unicode's confusables.txt, which doesn't map to
+// the most similar ASCII character but to a canonical one: "I" and "1" are
+// both confused with "l", and "0" with "O".
+func (c Codepoint) Confusable() string {
+	return confusables[c.Codepoint]
+}
+
 // FormatCodepoint formats the codepoint in Unicode notation.
 func (c Codepoint) FormatCodepoint() string {
 	return fmt.Sprintf("U+%04X", c.Codepoint)
@@ -395,6 +452,18 @@ func (c Codepoint) UTF16(bigEndian bool) []byte {
 	return p
 }
 
+// UTF32 gets the UTF-32 representation of this codepoint.
+//
+// The default is to use Little-Endian encoding; set bigEndian to use Big-Endian
+// encoding.
+func (c Codepoint) UTF32(bigEndian bool) []byte {
+	p := []byte{byte(c.Codepoint), byte(c.Codepoint >> 8), byte(c.Codepoint >> 16), byte(c.Codepoint >> 24)}
+	if bigEndian {
+		p[0], p[1], p[2], p[3] = p[3], p[2], p[1], p[0]
+	}
+	return p
+}
+
 // JSON gets the JSON representation.
 func (c Codepoint) JSON() string {
 	u := fmt.Sprintf("%x", c.UTF16(true))
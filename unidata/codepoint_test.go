@@ -0,0 +1,37 @@
+package unidata
+
+import (
+	"testing"
+)
+
+func TestSetPUANames(t *testing.T) {
+	t.Cleanup(func() { SetPUANames(nil) })
+
+	err := SetPUANames(map[rune]string{0xe000: "first", 0xe725: "nf-dev-git_branch", 0xf0001: "nf-md-ab_testing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   rune
+		want string
+	}{
+		{0xe000, "first"},
+		{0xe725, "nf-dev-git_branch"},
+		{0xe726, "<Private Use>"},
+		{0xf0001, "nf-md-ab_testing"},
+		{0x2713, "CHECK MARK"},
+	}
+	for _, tt := range tests {
+		if have, _ := Find(tt.in); have.Name() != tt.want {
+			t.Errorf("U+%04X\nhave: %q\nwant: %q", tt.in, have.Name(), tt.want)
+		}
+	}
+
+	err = SetPUANames(map[rune]string{0x2713: "tick"})
+	if want := "unidata.SetPUANames: U+2713 is not in a Private Use Area"; err == nil || err.Error() != want {
+		t.Fatalf("wrong error\nhave: %v\nwant: %v", err, want)
+	}
+	if have, _ := Find(0xe725); have.Name() != "nf-dev-git_branch" {
+		t.Errorf("names changed after error: %q", have.Name())
+	}
+}
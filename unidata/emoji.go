@@ -1,7 +1,9 @@
 package unidata
 
 import (
+	"slices"
 	"strings"
+	"sync"
 )
 
 // Emoji is an emoji sequence.
@@ -14,21 +16,53 @@ type (
 		CLDR       []string      // CLDR names
 		skinTones  bool          // Supports skintones?
 		gender     int           // Supports setting gender?
+		hair       bool          // Supports hair styles?
+		direction  bool          // Supports facing right?
+		version    EmojiVersion  // Emoji version this was introduced in.
+		status     EmojiStatus   // Qualification status.
 	}
 	EmojiGroup    uint8  // Emoji group.
 	EmojiSubgroup uint16 // Emoji subgroup.
+	EmojiVersion  uint8  // Emoji version.
+	EmojiStatus   uint8  // Emoji qualification status.
 
 	// EmojiGenderType   uint8
 	// EmojiSkintoneType uint8
 )
 
+// Emoji qualification status, as listed in emoji-test.txt.
+//
+// Only fully-qualified emojis should be generated by keyboards and other input
+// methods; the others are "valid", but lack one or more variation selectors
+// and may not be displayed as an emoji.
+const (
+	StatusFullyQualified     = EmojiStatus(iota) // Fully-qualified emoji.
+	StatusMinimallyQualified                     // Minimally-qualified emoji.
+	StatusUnqualified                            // Unqualified emoji.
+	StatusComponent                              // Emoji component (skin tone, hair style).
+)
+
+// EmojiStatuses is a list of all emoji qualification statuses.
+var EmojiStatuses = map[EmojiStatus]string{
+	StatusFullyQualified:     "fully-qualified",
+	StatusMinimallyQualified: "minimally-qualified",
+	StatusUnqualified:        "unqualified",
+	StatusComponent:          "component",
+}
+
 func (e EmojiGroup) String() string    { return EmojiGroups[e].Name }
 func (e EmojiSubgroup) String() string { return EmojiSubgroups[e].Name }
+func (e EmojiVersion) String() string  { return EmojiVersions[e] }
+func (e EmojiStatus) String() string   { return EmojiStatuses[e] }
 
 func (e Emoji) Group() EmojiGroup       { return e.group }
 func (e Emoji) Subgroup() EmojiSubgroup { return e.subgroup }
 func (e Emoji) Skintones() bool         { return e.skinTones }
 func (e Emoji) Genders() bool           { return e.gender > 0 }
+func (e Emoji) HairStyles() bool        { return e.hair }
+func (e Emoji) Directions() bool        { return e.direction }
+func (e Emoji) Version() EmojiVersion   { return e.version }
+func (e Emoji) Status() EmojiStatus     { return e.status }
 
 func (e Emoji) String() string {
 	if len(e.Codepoints) == 0 { // Should never happen.
@@ -41,7 +75,7 @@ func (e Emoji) String() string {
 	// 1F1FF 1F1FC                                 # 🇿🇼 E2.0 flag: Zimbabwe
 	// 1F3F4 E0067 E0062 E0065 E006E E0067 E007F   # 🏴󠁧󠁢󠁥󠁮󠁧󠁿 E5.0 flag: England
 	if (e.Codepoints[0] >= 0x1f1e6 && e.Codepoints[0] <= 0x1f1ff) ||
-		(len(e.Codepoints) > 1 && e.Codepoints[1] == 0xe0067) {
+		(len(e.Codepoints) > 1 && e.Codepoints[1] >= 0xe0020 && e.Codepoints[1] <= 0xe007f) {
 		for _, cp := range e.Codepoints {
 			c += string(rune(cp))
 		}
@@ -77,8 +111,8 @@ const (
 	genderRole = 2
 )
 
-// EmojiModifier is a modifier to apply to an emoji to change the gender(s) or
-// skintone(s).
+// EmojiModifier is a modifier to apply to an emoji to change the gender(s),
+// skintone(s), hair style, or direction.
 type EmojiModifier uint16
 
 // EmojiModifier values.
@@ -92,8 +126,37 @@ const (
 	ModMedium                                 // Medium skin tone
 	ModMediumDark                             // Mediun dark skin tone
 	ModDark                                   // Dark skin tone
+	ModNoHair                                 // No hair style
+	ModRedHair                                // Red hair
+	ModCurlyHair                              // Curly hair
+	ModWhiteHair                              // White hair
+	ModBald                                   // Bald
+	ModFacingLeft                             // Facing left (the default)
+	ModFacingRight                            // Facing right
+
+	ModAllGenders    = ModPerson | ModMale | ModFemale
+	ModAllTones      = ModNone | ModLight | ModMediumLight | ModMedium | ModMediumDark | ModDark
+	ModAllHair       = ModNoHair | ModRedHair | ModCurlyHair | ModWhiteHair | ModBald
+	ModAllDirections = ModFacingLeft | ModFacingRight
 )
 
+var modnames = []string{"person", "man", "woman", "", "light skin tone",
+	"medium-light skin tone", "medium skin tone", "medium-dark skin tone",
+	"dark skin tone", "", "red hair", "curly hair", "white hair", "bald",
+	"", "facing right"}
+
+// String gets the names of all modifiers, separated by a comma. The "none"
+// modifiers such as ModNone and ModFacingLeft are omitted.
+func (m EmojiModifier) String() string {
+	names := make([]string, 0, 2)
+	for i, n := range modnames {
+		if m&(1<<i) != 0 && n != "" {
+			names = append(names, n)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
 func isEmoji(e Emoji, want ...rune) bool {
 	if len(e.Codepoints) != len(want) {
 		return false
@@ -125,9 +188,9 @@ func (e Emoji) With(mod EmojiModifier, selmod ...EmojiModifier) Emoji {
 	//   1FAF1 1F3FC 200D 1FAF2 1F3FD   handshake: medium-light skin tone, medium skin tone
 	if len(selmod) > 0 && isEmoji(e, 0x1F91D) {
 		e.Codepoints = []rune{
-			0x1FAF1, tonemap[mod&^(ModPerson|ModMale|ModFemale)],
+			0x1FAF1, tonemap[mod&ModAllTones],
 			0x200D,
-			0x1FAF2, tonemap[selmod[0]&^(ModPerson|ModMale|ModFemale)],
+			0x1FAF2, tonemap[selmod[0]&ModAllTones],
 		}
 		return e
 	}
@@ -211,8 +274,10 @@ func (e Emoji) With(mod EmojiModifier, selmod ...EmojiModifier) Emoji {
 	//	// TODO
 	//}
 
-	e = e.applyGender(mod & (ModPerson | ModMale | ModFemale))
-	e = e.applyTone(mod &^ (ModPerson | ModMale | ModFemale))
+	e = e.applyGender(mod & ModAllGenders)
+	e = e.applyTone(mod & ModAllTones)
+	e = e.applyHair(mod & ModAllHair)
+	e = e.applyDirection(mod & ModAllDirections)
 	return e
 }
 
@@ -235,19 +300,31 @@ func (e Emoji) applyGender(g EmojiModifier) Emoji {
 	//   1F9D1 1F3FB 200D 1F692        # 🧑🏻‍🚒 E12.1 firefighter: light skin tone
 	//   1F469 200D 1F692              # 👩‍🚒 E4.0 woman firefighter
 	//   1F469 1F3FB 200D 1F692        # 👩🏻‍🚒 E4.0 woman firefighter: light skin tone
+	//
+	// The name is prefixed with "man" or "woman", unless it starts with
+	// "person", in which case that's replaced:
+	//   1F9D1 200D 1F9AF              # 🧑‍🦯 E12.1 person with white cane
+	//   1F468 200D 1F9AF              # 👨‍🦯 E12.0 man with white cane
 	case e.gender == genderRole:
 		switch g {
 		case ModMale:
-			e.Name = "man " + e.Name
+			e.Name = genderName(e.Name, "man")
 			e.Codepoints = append([]rune{0x1f468}, e.Codepoints[1:]...)
 		case ModFemale:
-			e.Name = "woman " + e.Name
+			e.Name = genderName(e.Name, "woman")
 			e.Codepoints = append([]rune{0x1f469}, e.Codepoints[1:]...)
 		}
 	}
 	return e
 }
 
+func genderName(name, g string) string {
+	if strings.HasPrefix(name, "person") {
+		return g + strings.TrimPrefix(name, "person")
+	}
+	return g + " " + name
+}
+
 var tonemap = map[EmojiModifier]rune{
 	ModNone:        0,
 	ModLight:       0x1f3fb,
@@ -260,20 +337,260 @@ var tonenames = map[EmojiModifier]string{
 	ModNone:        "",
 	ModLight:       "light",
 	ModMediumLight: "medium-light",
-	ModMedium:      "mediun",
+	ModMedium:      "medium",
 	ModMediumDark:  "medium-dark",
 	ModDark:        "dark",
 }
 
-// Skintone always comes after the base emoji and doesn't required a ZWJ.
+// Skintone always comes after the base emoji and doesn't required a ZWJ. The
+// VS16 after the base emoji is removed, if any:
+//
+//	1F3CC FE0F 200D 2642 FE0F     # 🏌️‍♂️ E4.0 man golfing
+//	1F3CC 1F3FB 200D 2642 FE0F    # 🏌🏻‍♂️ E4.0 man golfing: light skin tone
+//
+// Sequences of several people get the skin tone for every person:
+//
+//	1F9D1 1F3FB 200D 1F91D 200D 1F9D1 1F3FB    # 🧑🏻‍🤝‍🧑🏻 E12.0 people holding hands: light skin tone
 func (e Emoji) applyTone(t EmojiModifier) Emoji {
 	if tcp := tonemap[t]; tcp > 0 {
 		e.Name = e.Name + ": " + tonenames[t] + " skin tone"
-		e.Codepoints = append(append([]rune{e.Codepoints[0]}, tcp), e.Codepoints[1:]...)
-		l := len(e.Codepoints) - 1
-		if e.Codepoints[l] == 0xfe0f {
-			e.Codepoints = e.Codepoints[:l]
+
+		cp := make([]rune, 0, len(e.Codepoints)+2)
+		for i, c := range e.Codepoints {
+			if i == 1 && c == 0xfe0f {
+				continue
+			}
+			cp = append(cp, c)
+			if i == 0 || (isPerson(e.Codepoints[0]) && isPerson(c)) {
+				cp = append(cp, tcp)
+			}
+		}
+		e.Codepoints = cp
+	}
+	return e
+}
+
+func isPerson(r rune) bool { return r == 0x1f9d1 || r == 0x1f468 || r == 0x1f469 }
+
+var hairmap = map[EmojiModifier]rune{
+	ModNoHair:    0,
+	ModRedHair:   0x1f9b0,
+	ModCurlyHair: 0x1f9b1,
+	ModBald:      0x1f9b2,
+	ModWhiteHair: 0x1f9b3,
+}
+var hairnames = map[EmojiModifier]string{
+	ModNoHair:    "",
+	ModRedHair:   "red hair",
+	ModCurlyHair: "curly hair",
+	ModBald:      "bald",
+	ModWhiteHair: "white hair",
+}
+
+// Hair style is added at the end, after a ZWJ:
+//
+//	1F9D1 200D 1F9B0              # 🧑‍🦰 E12.1 person: red hair
+//	1F9D1 1F3FB 200D 1F9B0        # 🧑🏻‍🦰 E12.1 person: light skin tone, red hair
+func (e Emoji) applyHair(h EmojiModifier) Emoji {
+	if hcp := hairmap[h]; hcp > 0 && e.hair {
+		if strings.Contains(e.Name, ":") {
+			e.Name += ", " + hairnames[h]
+		} else {
+			e.Name += ": " + hairnames[h]
+		}
+		e.Codepoints = append(e.Codepoints, hcp)
+	}
+	return e
+}
+
+// Facing right is added at the end, after a ZWJ, and goes before the skin tone
+// in the name:
+//
+//	1F6B6 200D 27A1 FE0F                     # 🚶‍➡️ E15.1 person walking facing right
+//	1F6B6 1F3FB 200D 27A1 FE0F               # 🚶🏻‍➡️ E15.1 person walking facing right: light skin tone
+//	1F6B6 200D 2640 FE0F 200D 27A1 FE0F      # 🚶‍♀️‍➡️ E15.1 woman walking facing right
+func (e Emoji) applyDirection(d EmojiModifier) Emoji {
+	if d == ModFacingRight && e.direction {
+		name, mod, ok := strings.Cut(e.Name, ":")
+		e.Name = name + " facing right"
+		if ok {
+			e.Name += ":" + mod
 		}
+		e.Codepoints = append(e.Codepoints, 0x27a1, 0xfe0f)
 	}
 	return e
 }
+
+// variants gets this emoji with all possible combinations of modifiers,
+// including the emoji itself (with a modifier of 0).
+func (e Emoji) variants() ([]Emoji, []EmojiModifier) {
+	var (
+		mods = []EmojiModifier{0}
+		add  = func(m ...EmojiModifier) {
+			l := len(mods)
+			for _, mm := range m {
+				for _, have := range mods[:l] {
+					mods = append(mods, have|mm)
+				}
+			}
+		}
+	)
+	if e.Genders() {
+		add(ModMale, ModFemale)
+	}
+	if e.Skintones() {
+		add(ModLight, ModMediumLight, ModMedium, ModMediumDark, ModDark)
+	}
+	if e.HairStyles() {
+		add(ModRedHair, ModCurlyHair, ModWhiteHair, ModBald)
+	}
+	if e.Directions() {
+		add(ModFacingRight)
+	}
+
+	all := make([]Emoji, 0, len(mods))
+	for _, m := range mods {
+		all = append(all, e.With(m))
+	}
+	return all, mods
+}
+
+var (
+	parseOnce  sync.Once
+	parseIndex map[string]parsed // Emoji without ZWJ and VS16 → base emoji.
+)
+
+type parsed struct {
+	base int // Index in Emojis
+	mods EmojiModifier
+}
+
+func buildParse() {
+	parseIndex = make(map[string]parsed, len(Emojis)*2)
+	for i, e := range Emojis {
+		if e.status != StatusFullyQualified {
+			continue
+		}
+		variants, mods := e.variants()
+		for j, v := range variants {
+			k := normEmoji(string(v.Codepoints))
+			if _, ok := parseIndex[k]; !ok {
+				parseIndex[k] = parsed{base: i, mods: mods[j]}
+			}
+		}
+	}
+	// Add the minimally-qualified and unqualified emojis as well; these are
+	// usually identical to the fully-qualified ones once the VS16 is removed.
+	for i, e := range Emojis {
+		k := normEmoji(string(e.Codepoints))
+		if _, ok := parseIndex[k]; !ok {
+			parseIndex[k] = parsed{base: i}
+		}
+	}
+}
+
+// Sequences for multiple people, which can set the gender and skin tone for
+// every person individually. These are matched after removing the skin tones.
+var multiPerson = []struct {
+	middle []rune // Between the two people.
+	base   []rune
+}{
+	{[]rune{0x2764, 0x1f48b}, []rune{0x1f48f}},           // kiss
+	{[]rune{0x2764}, []rune{0x1f491}},                    // couple with heart
+	{[]rune{0x1f91d}, []rune{0x1f9d1, 0x1f91d, 0x1f9d1}}, // people holding hands
+}
+
+var personGender = map[rune]EmojiModifier{
+	0x1f9d1: ModPerson,
+	0x1f468: ModMale,
+	0x1f469: ModFemale,
+}
+
+// ParseEmoji parses an emoji sequence in to the base emoji and the modifiers,
+// which is the reverse of [Emoji.With]. For example "👍🏿" returns 👍 with
+// ModDark, and "👩‍🦰" returns 🧑 with ModFemale|ModRedHair.
+//
+// Emojis with modifiers for several people, such as "🫱🏻‍🫲🏿" (handshake:
+// light skin tone, dark skin tone) or "👩‍❤️‍👨" (couple with heart: woman, man)
+// return all modifiers combined; the order is lost.
+//
+// The ok return value is false if s isn't an emoji.
+func ParseEmoji(s string) (base Emoji, mods EmojiModifier, ok bool) {
+	parseOnce.Do(buildParse)
+
+	n := normEmoji(s)
+	if p, ok := parseIndex[n]; ok {
+		return Emojis[p.base], p.mods, true
+	}
+
+	// Multiple people with different skin tones.
+	var tones EmojiModifier
+	cp := slices.DeleteFunc([]rune(n), func(r rune) bool {
+		if r >= 0x1f3fb && r <= 0x1f3ff {
+			tones |= ModLight << (r - 0x1f3fb)
+			return true
+		}
+		return false
+	})
+	if len(cp) < 2 {
+		return Emoji{}, 0, false
+	}
+	return parseMultiPerson(cp, tones)
+}
+
+func parseMultiPerson(cp []rune, tones EmojiModifier) (Emoji, EmojiModifier, bool) {
+	find := func(want []rune) (Emoji, bool) {
+		p, ok := parseIndex[string(want)]
+		return Emojis[p.base], ok && p.mods == 0
+	}
+
+	// 🫱🏻‍🫲🏿 handshake
+	if isEmoji(Emoji{Codepoints: cp}, 0x1faf1, 0x1faf2) {
+		e, ok := find([]rune{0x1f91d})
+		return e, tones, ok
+	}
+
+	// 👨‍👩‍👧 family: man, woman, girl
+	family := true
+	for _, r := range cp {
+		if r != 0x1f468 && r != 0x1f469 && r != 0x1f466 && r != 0x1f467 && r != 0x1f9d1 && r != 0x1f9d2 {
+			family = false
+			break
+		}
+	}
+	if family && tones == 0 {
+		var g EmojiModifier
+		for _, r := range cp {
+			g |= personGender[r]
+		}
+		e, ok := find([]rune{0x1f46a})
+		return e, g &^ ModPerson, ok
+	}
+
+	// 👩🏻‍❤️‍👨🏿 couple with heart: woman, man, light skin tone, dark skin tone
+	g1, ok1 := personGender[cp[0]]
+	g2, ok2 := personGender[cp[len(cp)-1]]
+	if !ok1 || !ok2 {
+		return Emoji{}, 0, false
+	}
+	for _, m := range multiPerson {
+		if slices.Equal(cp[1:len(cp)-1], m.middle) {
+			e, ok := find(m.base)
+			return e, (g1 | g2 | tones) &^ ModPerson, ok
+		}
+	}
+	return Emoji{}, 0, false
+}
+
+// IsRGI reports if the emoji sequence is a fully-qualified emoji that's
+// "recommended for general interchange"; that is, it's listed in
+// emoji-test.txt. This includes all skin tone and gender variants.
+//
+// Other sequences may be valid, but are unlikely to be displayed correctly.
+func IsRGI(s string) bool {
+	_, ok := rgi[s]
+	return ok
+}
+
+// Valid reports if this emoji is a fully-qualified RGI sequence; see [IsRGI].
+func (e Emoji) Valid() bool { return IsRGI(e.String()) }
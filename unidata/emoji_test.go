@@ -11,6 +11,7 @@ func TestEmoji(t *testing.T) {
 	var (
 		shrug     = Emoji{Codepoints: []rune("🤷"), Name: "person shrugging", gender: genderSign, skinTones: true}
 		handshake = Emoji{Codepoints: []rune("🤝"), Name: "handshake", skinTones: true}
+		holding   = Emoji{Codepoints: []rune("🧑🤝🧑"), Name: "people holding hands", skinTones: true}
 	)
 	tests := []struct {
 		mod  []EmojiModifier
@@ -25,7 +26,7 @@ func TestEmoji(t *testing.T) {
 			Emoji{Codepoints: []rune("🤷♀\ufe0f")}},
 		{[]EmojiModifier{ModFemale | ModDark},
 			shrug,
-			Emoji{Codepoints: []rune("🤷🏿♀")}},
+			Emoji{Codepoints: []rune("🤷🏿♀\ufe0f")}},
 
 		{[]EmojiModifier{ModDark},
 			handshake,
@@ -33,6 +34,9 @@ func TestEmoji(t *testing.T) {
 		{[]EmojiModifier{ModDark, ModLight},
 			handshake,
 			Emoji{Codepoints: []rune("🫱🏿‍🫲🏻")}},
+		{[]EmojiModifier{ModDark},
+			holding,
+			Emoji{Codepoints: []rune("🧑🏿🤝🧑🏿")}},
 	}
 
 	for _, tt := range tests {
@@ -48,3 +52,125 @@ func TestEmoji(t *testing.T) {
 		})
 	}
 }
+
+func TestEmojiHairDirection(t *testing.T) {
+	find := func(name string) Emoji {
+		for _, e := range Emojis {
+			if e.Name == name {
+				return e
+			}
+		}
+		t.Fatalf("no emoji %q", name)
+		return Emoji{}
+	}
+
+	tests := []struct {
+		in       string
+		mod      EmojiModifier
+		want     string
+		wantName string
+	}{
+		{"person", ModRedHair, "🧑‍🦰", "person: red hair"},
+		{"person", ModMale | ModCurlyHair, "👨‍🦱", "man: curly hair"},
+		{"person", ModFemale | ModWhiteHair, "👩‍🦳", "woman: white hair"},
+		{"person", ModLight | ModBald, "🧑🏻‍🦲", "person: light skin tone, bald"},
+		{"person", ModNoHair, "🧑", "person"},
+		{"person", ModFacingRight, "🧑", "person"},
+		{"person shrugging", ModRedHair, "🤷", "person shrugging"},
+
+		{"person walking", ModFacingRight, "🚶‍➡️", "person walking facing right"},
+		{"person walking", ModFacingLeft, "🚶", "person walking"},
+		{"person walking", ModFemale | ModFacingRight, "🚶‍♀️‍➡️", "woman walking facing right"},
+		{"person walking", ModDark | ModFacingRight, "🚶🏿‍➡️", "person walking facing right: dark skin tone"},
+		{"person with white cane", ModMale | ModFacingRight, "👨‍🦯‍➡️", "man with white cane facing right"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			have := find(tt.in).With(tt.mod)
+			if have.String() != tt.want || have.Name != tt.wantName {
+				t.Errorf("\nhave: %q %q\nwant: %q %q", have.String(), have.Name, tt.want, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestParseEmoji(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantBase string
+		wantMods EmojiModifier
+		wantOK   bool
+	}{
+		{"", "", 0, false},
+		{"a", "", 0, false},
+		{"👍", "👍", 0, true},
+		{"👍🏿", "👍", ModDark, true},
+		{"❤", "❤️", 0, true},
+		{"🤷‍♀️", "🤷", ModFemale, true},
+		{"🤷🏽‍♂️", "🤷", ModMale | ModMedium, true},
+		{"👩‍🚒", "🧑‍🚒", ModFemale, true},
+		{"👨🏻‍🚒", "🧑‍🚒", ModMale | ModLight, true},
+		{"👩‍🦰", "🧑", ModFemale | ModRedHair, true},
+		{"🧑🏿‍🦲", "🧑", ModDark | ModBald, true},
+		{"🚶‍♀️‍➡️", "🚶", ModFemale | ModFacingRight, true},
+		{"🫱🏻‍🫲🏿", "🤝", ModLight | ModDark, true},
+		{"👩‍❤️‍👨", "💑", ModFemale | ModMale, true},
+		{"👩🏻‍❤️‍💋‍👩🏾", "💏", ModFemale | ModLight | ModMediumDark, true},
+		{"🧑🏻‍🤝‍🧑🏻", "🧑‍🤝‍🧑", ModLight, true},
+		{"👨‍👩‍👧‍👦", "👪", ModMale | ModFemale, true},
+		{"🇳🇱", "🇳🇱", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			base, mods, ok := ParseEmoji(tt.in)
+			if base.String() != tt.wantBase || mods != tt.wantMods || ok != tt.wantOK {
+				t.Errorf("\nhave: %q %q %t\nwant: %q %q %t",
+					base.String(), mods, ok, tt.wantBase, tt.wantMods, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEmojiModifierString(t *testing.T) {
+	tests := []struct {
+		in   EmojiModifier
+		want string
+	}{
+		{0, ""},
+		{ModNone, ""},
+		{ModFemale, "woman"},
+		{ModMale | ModDark | ModRedHair | ModFacingRight, "man, dark skin tone, red hair, facing right"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if have := tt.in.String(); have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestRGI(t *testing.T) {
+	for _, s := range []string{"🤷🏿‍♀️", "🇳🇱", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "❤️"} {
+		if !IsRGI(s) {
+			t.Errorf("IsRGI(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "a", "🤷🏿♀", "🇦🇦", "❤"} {
+		if IsRGI(s) {
+			t.Errorf("IsRGI(%q) = true", s)
+		}
+	}
+
+	for _, e := range Emojis {
+		if e.Status() != StatusFullyQualified {
+			continue
+		}
+		variants, mods := e.variants()
+		for i, v := range variants {
+			if !v.Valid() {
+				t.Errorf("not RGI: %s %q %q (%s)", e.String(), v.String(), v.Name, mods[i])
+			}
+		}
+	}
+}
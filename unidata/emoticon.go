@@ -0,0 +1,141 @@
+package unidata
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ASCII emoticons, by the emoji they map to. The first one is the "canonical"
+// form.
+//
+// Things like "8)" and "D:" are common in regular text ("drive D: is full"), so
+// they're not included.
+var emoticons = map[string][]string{
+	"🙂":  {":)", ":-)", "=)", ":]", "(:"},
+	"😃":  {":D", ":-D", "=D"},
+	"😆":  {"xD", "XD"},
+	"😊":  {"^_^", "^^", "^.^"},
+	"🙁":  {":(", ":-(", "=(", ":[", "):"},
+	"😢":  {":'(", ":'-(", ";("},
+	"🥲":  {":')", ":'-)"},
+	"😉":  {";)", ";-)", ";D"},
+	"😛":  {":P", ":-P", ":p", ":-p", "=P"},
+	"😜":  {";P", ";-P", ";p", ";-p"},
+	"😮":  {":O", ":-O", ":o", ":-o"},
+	"😐":  {":|", ":-|"},
+	"😑":  {"-_-"},
+	"😕":  {":/", ":-/", `:\`, `:-\`},
+	"😘":  {":*", ":-*"},
+	"😎":  {"B-)", "8-)"},
+	"😇":  {"O:)", "O:-)", "0:)"},
+	"😈":  {">:)", ">:-)"},
+	"😠":  {">:(", ">:-("},
+	"😳":  {":$", ":-$"},
+	"🤐":  {":X", ":-X", ":x"},
+	"😖":  {":S", ":-S", ":s"},
+	"🤨":  {"o_O", "O_o", "o.O", "O.o"},
+	"😺":  {":3"},
+	"❤️": {"<3"},
+	"💔":  {"</3"},
+	"🙌":  {`\o/`},
+	"🤷":  {`¯\_(ツ)_/¯`, `¯\(ツ)/¯`},
+}
+
+var (
+	emoticonOnce sync.Once
+	emoticonRev  map[string]string   // Emoticon → emoji.
+	emoticonFwd  map[string][]string // Emoji without ZWJ and VS16 → emoticons.
+	emoticonList []string            // Emoticons sorted by length, longest first.
+)
+
+func buildEmoticons() {
+	emoticonRev = make(map[string]string)
+	emoticonFwd = make(map[string][]string, len(emoticons))
+	for e, list := range emoticons {
+		emoticonFwd[normEmoji(e)] = list
+		for _, l := range list {
+			emoticonRev[l] = e
+			emoticonList = append(emoticonList, l)
+		}
+	}
+	sort.Slice(emoticonList, func(i, j int) bool {
+		if len(emoticonList[i]) == len(emoticonList[j]) {
+			return emoticonList[i] < emoticonList[j]
+		}
+		return len(emoticonList[i]) > len(emoticonList[j])
+	})
+}
+
+// Emoticons gets the ASCII emoticons for this emoji, such as ":)" for 🙂.
+func (e Emoji) Emoticons() []string {
+	emoticonOnce.Do(buildEmoticons)
+	return emoticonFwd[normEmoji(string(e.Codepoints))]
+}
+
+// FromEmoticon gets the emoji for an ASCII emoticon such as ":)".
+func FromEmoticon(emoticon string) (string, bool) {
+	emoticonOnce.Do(buildEmoticons)
+	e, ok := emoticonRev[emoticon]
+	return e, ok
+}
+
+// ReplaceEmoticons replaces all ASCII emoticons in the text with emojis.
+//
+// Emoticons are only replaced if they're surrounded by whitespace, optionally
+// followed by punctuation, so "http://" or "(a:b)" are left alone.
+func ReplaceEmoticons(text string) string {
+	emoticonOnce.Do(buildEmoticons)
+
+	b := new(strings.Builder)
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		if emoticonStart(text[:i]) {
+			var found bool
+			for _, em := range emoticonList {
+				if !strings.HasPrefix(text[i:], em) {
+					continue
+				}
+				if emoticonEnd(text[i+len(em):]) {
+					b.WriteString(emoticonRev[em])
+					i += len(em)
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+		}
+
+		_, s := utf8.DecodeRuneInString(text[i:])
+		b.WriteString(text[i : i+s])
+		i += s
+	}
+	return b.String()
+}
+
+// emoticonStart reports if an emoticon can start after the text before: it
+// must be at the start or after whitespace.
+func emoticonStart(before string) bool {
+	r, _ := utf8.DecodeLastRuneInString(before)
+	return before == "" || unicode.IsSpace(r)
+}
+
+// emoticonEnd reports if an emoticon can end before the text after: it must be
+// at the end, before whitespace, or before punctuation that ends a sentence.
+func emoticonEnd(after string) bool {
+	if after == "" {
+		return true
+	}
+	r, n := utf8.DecodeRuneInString(after)
+	if strings.ContainsRune(".,!?;", r) {
+		if len(after) == n {
+			return true
+		}
+		r, _ = utf8.DecodeRuneInString(after[n:])
+	}
+	return unicode.IsSpace(r)
+}
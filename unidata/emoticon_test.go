@@ -0,0 +1,42 @@
+package unidata
+
+import "testing"
+
+func TestEmoticons(t *testing.T) {
+	// Make sure every emoticon maps to an existing fully-qualified emoji.
+	for e := range emoticons {
+		var found bool
+		for _, ee := range Emojis {
+			if string(ee.Codepoints) == e && ee.Status() == StatusFullyQualified {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("not a fully-qualified emoji: %q", e)
+		}
+	}
+}
+
+func TestReplaceEmoticons(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{":)", "🙂"},
+		{"hello :) <3", "hello 🙂 ❤️"},
+		{"sure :P, ok :-(.", "sure 😛, ok 🙁."},
+		{":):)", ":):)"},
+		{"http://example.com a:) (:b", "http://example.com a:) (:b"},
+		{`¯\_(ツ)_/¯ ok`, "🤷 ok"},
+		{"O:) O:)", "😇 😇"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have := ReplaceEmoticons(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,603 @@
+package unidata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	nameOnce  sync.Once
+	nameIndex map[string]rune // Upper-case name and aliases → codepoint.
+	htmlIndex map[string]rune // HTML entity name → codepoint.
+)
+
+func buildNames() {
+	nameIndex = make(map[string]rune, len(Codepoints))
+	for cp, info := range Codepoints {
+		if info.name == "" || info.name[0] == '<' {
+			continue
+		}
+		nameIndex[info.name] = cp
+		// Control characters: "LINE FEED (LF)"
+		if n, abbr, ok := strings.Cut(strings.TrimSuffix(info.name, ")"), " ("); ok {
+			nameIndex[n], nameIndex[abbr] = cp, cp
+		}
+	}
+	for cp, n := range names {
+		for _, a := range n.aliases {
+			if _, ok := nameIndex[strings.ToUpper(a)]; !ok {
+				nameIndex[strings.ToUpper(a)] = cp
+			}
+		}
+	}
+	htmlIndex = make(map[string]rune, len(htmlEntities))
+	for cp, n := range htmlEntities {
+		htmlIndex[n] = cp
+	}
+}
+
+// FromName finds a codepoint by name or alias, such as "CHECK MARK". The name
+// is case-insensitive.
+//
+// Codepoints in ranges that don't have an individual name can be found with
+// the name and hex codepoint, such as "CJK UNIFIED IDEOGRAPH-4E00".
+func FromName(name string) (Codepoint, bool) {
+	nameOnce.Do(buildNames)
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if cp, ok := nameIndex[name]; ok {
+		return Find(cp)
+	}
+
+	if i := strings.LastIndexByte(name, '-'); i > -1 {
+		cp, err := strconv.ParseUint(name[i+1:], 16, 32)
+		if err != nil {
+			return Codepoint{}, false
+		}
+		for _, r := range codepointRanges {
+			if rune(cp) >= r.rng[0] && rune(cp) <= r.rng[1] {
+				return Find(rune(cp))
+			}
+		}
+	}
+	return Codepoint{}, false
+}
+
+// ParseEscapes decodes all escape sequences in s; everything that's not an
+// escape sequence is kept as-is. The recognized escapes are:
+//
+//	\u2713 \u{2713}      JavaScript, Rust, JSON, Java, Python, etc.
+//	\U0001F600           Python, C, Go
+//	\x{2713}             Perl
+//	\xe2\x9c\x93         Bytes, as UTF-8
+//	%E2%9C%93            Bytes, as UTF-8 (URL encoding)
+//	&#x2713; &#10003;    HTML/XML character references
+//	&check;              HTML named entities
+//	\N{CHECK MARK}       Python, Perl; the name is case-insensitive
+//	\2713                CSS; one optional space after it is removed
+//	\n \t \0 \\ etc.     The usual single-character escapes
+//
+// UTF-16 surrogate pairs such as \ud83d\ude00 or &#xd83d;&#xde00; are combined
+// to one codepoint; it's an error if a surrogate isn't paired.
+//
+// Bytes that aren't valid UTF-8 are decoded as Latin-1, like Python does:
+// \xe9 is é.
+//
+// A backslash followed by two or more hex digits is always a CSS escape, so
+// \e9 is é and not ESC followed by "9", and there are no octal escapes
+// (except \0).
+//
+// If the entire string is quoted with ", ', or ` it's unquoted, including
+// any prefix such as u"é", b"..", or L"..". Escapes in raw strings (r"..")
+// are not decoded.
+func ParseEscapes(s string) ([]rune, error) {
+	s, raw := unquote(s)
+	if raw {
+		return []rune(s), nil
+	}
+
+	u := unescaper{out: make([]rune, 0, len(s))}
+	for i := 0; i < len(s); {
+		var (
+			n   int
+			err error
+		)
+		switch s[i] {
+		case '\\':
+			n, err = u.backslash(s[i:])
+		case '&':
+			n, err = u.entity(s[i:])
+		case '%':
+			n = u.percent(s[i:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unidata.ParseEscapes: %w", err)
+		}
+		if n == 0 {
+			var r rune
+			r, n = utf8.DecodeRuneInString(s[i:])
+			err = u.rune(r)
+			if err != nil {
+				return nil, fmt.Errorf("unidata.ParseEscapes: %w", err)
+			}
+		}
+		i += n
+	}
+	if err := u.finish(); err != nil {
+		return nil, fmt.Errorf("unidata.ParseEscapes: %w", err)
+	}
+	return u.out, nil
+}
+
+// unquote removes the quotes from a string such as "x", 'x', or u"x".
+func unquote(s string) (string, bool) {
+	p := 0
+	for p < len(s) && p < 2 && strings.IndexByte("uUbBrRfFL8", s[p]) > -1 {
+		p++
+	}
+	q := s[p:]
+	if len(q) < 2 || strings.IndexByte("\"'`", q[0]) == -1 || q[len(q)-1] != q[0] {
+		return s, false
+	}
+	return q[1 : len(q)-1], strings.ContainsAny(s[:p], "rR")
+}
+
+type unescaper struct {
+	out  []rune
+	buf  []byte // Bytes from \xHH and %HH, which are decoded together.
+	high rune   // High surrogate, waiting for the low surrogate.
+}
+
+var simpleEscapes = map[byte]rune{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'e': 0x1b, '0': 0, '\\': '\\', '"': '"', '\'': '\'', '`': '`', '/': '/',
+	'?': '?',
+}
+
+// backslash decodes the escape at the start of s, returning the number of
+// bytes used or 0 if this isn't an escape.
+func (u *unescaper) backslash(s string) (int, error) {
+	if len(s) < 2 {
+		return 0, nil
+	}
+	switch s[1] {
+	case 'u':
+		if len(s) > 2 && s[2] == '{' {
+			return u.braces(s, 2)
+		}
+		return u.hex(s, 2, 4, 4)
+	case 'U':
+		return u.hex(s, 2, 8, 8)
+	case 'x':
+		if len(s) > 2 && s[2] == '{' {
+			return u.braces(s, 2)
+		}
+		h := hexLen(s[2:], 2)
+		if h != 2 {
+			return 0, fmt.Errorf("invalid escape: %q", s[:2+h])
+		}
+		b, _ := strconv.ParseUint(s[2:4], 16, 8)
+		return 4, u.byte(byte(b))
+	case 'N':
+		end := strings.IndexByte(s, '}')
+		if len(s) < 3 || s[2] != '{' || end == -1 {
+			return 0, fmt.Errorf(`invalid escape: %q; \N needs a name in {braces}`, s[:min(len(s), 3)])
+		}
+		cp, ok := FromName(s[3:end])
+		if !ok {
+			return 0, fmt.Errorf("unknown codepoint name: %q", s[3:end])
+		}
+		return end + 1, u.rune(cp.Codepoint)
+	}
+
+	h := hexLen(s[1:], 6)
+	if r, ok := simpleEscapes[s[1]]; ok && h < 2 {
+		return 2, u.rune(r)
+	}
+	if h == 0 {
+		return 0, nil
+	}
+
+	// CSS: one optional whitespace character after the escape.
+	n, err := u.hex(s, 1, 1, h)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case strings.HasPrefix(s[n:], "\r\n"):
+		n += 2
+	case n < len(s) && strings.IndexByte(" \t\n\r\f", s[n]) > -1:
+		n++
+	}
+	return n, nil
+}
+
+// entity decodes the HTML entity at the start of s.
+func (u *unescaper) entity(s string) (int, error) {
+	if strings.HasPrefix(s, "&#x") || strings.HasPrefix(s, "&#X") {
+		if hexLen(s[3:], 8) == 0 {
+			return 0, nil
+		}
+		n, err := u.hex(s, 3, 1, 8)
+		if err != nil {
+			return 0, err
+		}
+		if n < len(s) && s[n] == ';' {
+			n++
+		}
+		return n, nil
+	}
+	if strings.HasPrefix(s, "&#") {
+		d := 2
+		for d < len(s) && d < 10 && s[d] >= '0' && s[d] <= '9' {
+			d++
+		}
+		if d == 2 {
+			return 0, nil
+		}
+		r, _ := strconv.ParseInt(s[2:d], 10, 32)
+		if d < len(s) && s[d] == ';' {
+			d++
+		}
+		return d, u.rune(rune(r))
+	}
+
+	end := strings.IndexByte(s, ';')
+	if end < 2 || end > 32 {
+		return 0, nil
+	}
+	nameOnce.Do(buildNames)
+	r, ok := htmlIndex[s[1:end]]
+	if !ok {
+		return 0, nil
+	}
+	return end + 1, u.rune(r)
+}
+
+// percent decodes the URL-encoded byte at the start of s.
+func (u *unescaper) percent(s string) int {
+	if hexLen(s[1:], 2) != 2 {
+		return 0
+	}
+	b, _ := strconv.ParseUint(s[1:3], 16, 8)
+	if u.byte(byte(b)) != nil {
+		return 0
+	}
+	return 3
+}
+
+// braces decodes \u{..} and \x{..}.
+func (u *unescaper) braces(s string, start int) (int, error) {
+	end := strings.IndexByte(s, '}')
+	if end == -1 {
+		return 0, fmt.Errorf("invalid escape: %q; no closing }", s[:start+1])
+	}
+	if h := hexLen(s[start+1:end], 8); h == 0 || start+1+h != end {
+		return 0, fmt.Errorf("invalid escape: %q", s[:end+1])
+	}
+	if _, err := u.hex(s[:end], start+1, 1, 8); err != nil {
+		return 0, err
+	}
+	return end + 1, nil
+}
+
+// hex decodes the hex number in s[start:], which has between minLen and maxLen
+// digits.
+func (u *unescaper) hex(s string, start, minLen, maxLen int) (int, error) {
+	h := hexLen(s[start:], maxLen)
+	if h < minLen {
+		return 0, fmt.Errorf("invalid escape: %q", s[:start+h])
+	}
+	r, _ := strconv.ParseUint(s[start:start+h], 16, 32)
+	return start + h, u.rune(rune(r))
+}
+
+// hexLen gets the number of hex digits at the start of s, up to max.
+func hexLen(s string, max int) int {
+	n := 0
+	for n < len(s) && n < max && isHex(s[n]) {
+		n++
+	}
+	return n
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func (u *unescaper) byte(b byte) error {
+	if u.high > 0 {
+		return fmt.Errorf("unpaired surrogate: U+%04X", u.high)
+	}
+	u.buf = append(u.buf, b)
+	return nil
+}
+
+func (u *unescaper) rune(r rune) error {
+	u.flush()
+	if u.high > 0 {
+		h := u.high
+		u.high = 0
+		if !utf16.IsSurrogate(r) || r < 0xdc00 {
+			return fmt.Errorf("unpaired surrogate: U+%04X", h)
+		}
+		u.out = append(u.out, utf16.DecodeRune(h, r))
+		return nil
+	}
+
+	switch {
+	case r >= 0xd800 && r <= 0xdbff:
+		u.high = r
+	case r >= 0xdc00 && r <= 0xdfff:
+		return fmt.Errorf("unpaired surrogate: U+%04X", r)
+	case r < 0 || r > utf8.MaxRune:
+		return fmt.Errorf("out of range: U+%X", r)
+	default:
+		u.out = append(u.out, r)
+	}
+	return nil
+}
+
+// flush decodes the bytes as UTF-8, falling back to Latin-1 for invalid
+// sequences.
+func (u *unescaper) flush() {
+	for len(u.buf) > 0 {
+		r, n := utf8.DecodeRune(u.buf)
+		if r == utf8.RuneError && n == 1 {
+			r = rune(u.buf[0])
+		}
+		u.out = append(u.out, r)
+		u.buf = u.buf[n:]
+	}
+}
+
+func (u *unescaper) finish() error {
+	u.flush()
+	if u.high > 0 {
+		return fmt.Errorf("unpaired surrogate: U+%04X", u.high)
+	}
+	return nil
+}
+
+// EscapeLang is a programming language or format to escape text for.
+type EscapeLang uint8
+
+// Escape languages.
+const (
+	EscapeGo     = EscapeLang(iota) // \u2713, \U0001f600
+	EscapePython                    // \xe9, \u2713, \U0001f600
+	EscapeJS                        // \u2713, \u{1f600}
+	EscapeJSON                      // \u2713, \ud83d\ude00
+	EscapeRust                      // \u{2713}
+	EscapeC                         // \u2713, \U0001f600; octal UTF-8 below U+00A0
+	EscapeJava                      // \u2713, \ud83d\ude00
+	EscapeCSS                       // \2713
+	EscapeSQL                       // \2713, \+01f600; for U&'..' strings
+	EscapeURL                       // %E2%9C%93
+	EscapeHTML                      // &check;, &#x2713;
+	EscapeXML                       // &#x2713;
+)
+
+// EscapeLangs is a list of all escape languages.
+var EscapeLangs = map[EscapeLang]string{
+	EscapeGo:     "go",
+	EscapePython: "python",
+	EscapeJS:     "js",
+	EscapeJSON:   "json",
+	EscapeRust:   "rust",
+	EscapeC:      "c",
+	EscapeJava:   "java",
+	EscapeCSS:    "css",
+	EscapeSQL:    "sql",
+	EscapeURL:    "url",
+	EscapeHTML:   "html",
+	EscapeXML:    "xml",
+}
+
+var escapeLangAliases = map[string]EscapeLang{
+	"py":         EscapePython,
+	"javascript": EscapeJS,
+	"ts":         EscapeJS,
+	"typescript": EscapeJS,
+	"rs":         EscapeRust,
+	"c++":        EscapeC,
+	"cpp":        EscapeC,
+	"kotlin":     EscapeJava,
+	"postgres":   EscapeSQL,
+}
+
+func (l EscapeLang) String() string { return EscapeLangs[l] }
+
+// FindEscapeLang finds an escape language by name.
+func FindEscapeLang(name string) (EscapeLang, bool) {
+	name = strings.ToLower(name)
+	for k, v := range EscapeLangs {
+		if v == name {
+			return k, true
+		}
+	}
+	l, ok := escapeLangAliases[name]
+	return l, ok
+}
+
+// Escape this codepoint for the given language. This always escapes the
+// codepoint, even if it doesn't need to be.
+//
+// Tab, newline, and carriage return use \t, \n, and \r in languages that
+// support it.
+func (c Codepoint) Escape(lang EscapeLang) string {
+	r := c.Codepoint
+	switch lang {
+	case EscapeCSS, EscapeSQL, EscapeURL, EscapeHTML, EscapeXML:
+	default:
+		switch r {
+		case '\t':
+			return `\t`
+		case '\n':
+			return `\n`
+		case '\r':
+			return `\r`
+		}
+	}
+
+	switch lang {
+	default:
+		return ""
+	case EscapeGo:
+		switch {
+		case r < 0x80:
+			return fmt.Sprintf(`\x%02x`, r)
+		case r <= 0xffff:
+			return fmt.Sprintf(`\u%04x`, r)
+		}
+		return fmt.Sprintf(`\U%08x`, r)
+	case EscapePython:
+		switch {
+		case r < 0x100:
+			return fmt.Sprintf(`\x%02x`, r)
+		case r <= 0xffff:
+			return fmt.Sprintf(`\u%04x`, r)
+		}
+		return fmt.Sprintf(`\U%08x`, r)
+	case EscapeC:
+		// Universal character names below U+00A0 are not allowed, except for
+		// $, @, and `. Use octal as \x doesn't have a maximum length.
+		if r < 0xa0 {
+			var b strings.Builder
+			for _, c := range c.UTF8() {
+				fmt.Fprintf(&b, `\%03o`, c)
+			}
+			return b.String()
+		}
+		if r <= 0xffff {
+			return fmt.Sprintf(`\u%04x`, r)
+		}
+		return fmt.Sprintf(`\U%08x`, r)
+	case EscapeJS:
+		if r <= 0xffff {
+			return fmt.Sprintf(`\u%04x`, r)
+		}
+		return fmt.Sprintf(`\u{%x}`, r)
+	case EscapeJSON, EscapeJava:
+		return c.JSON()
+	case EscapeRust:
+		if r < 0x80 {
+			return fmt.Sprintf(`\x%02x`, r)
+		}
+		return fmt.Sprintf(`\u{%x}`, r)
+	case EscapeCSS:
+		return fmt.Sprintf(`\%x`, r)
+	case EscapeSQL:
+		if r <= 0xffff {
+			return fmt.Sprintf(`\%04x`, r)
+		}
+		return fmt.Sprintf(`\+%06x`, r)
+	case EscapeURL:
+		var b strings.Builder
+		for _, c := range c.UTF8() {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+		return b.String()
+	case EscapeHTML:
+		return c.HTML()
+	case EscapeXML:
+		return c.XML()
+	}
+}
+
+// Escape the text for use in a string literal in the given language.
+//
+// Only characters that need to be escaped are escaped: quotes, backslashes,
+// control characters, and invisible characters such as U+200B ZERO WIDTH
+// SPACE or U+00A0 NO-BREAK SPACE. All non-ASCII characters are escaped if
+// nonASCII is true.
+//
+// The quotes are not added. Use double quotes for all languages except SQL;
+// the SQL escapes only work in U&'..' strings.
+func Escape(s string, lang EscapeLang, nonASCII bool) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range s {
+		if e, ok := escapeSpecial(r, lang); ok {
+			b.WriteString(e)
+			continue
+		}
+		if !mustEscape(r, lang, nonASCII) {
+			b.WriteRune(r)
+			continue
+		}
+
+		c, _ := Find(r)
+		b.WriteString(c.Escape(lang))
+		if lang == EscapeCSS {
+			// CSS escapes end at the first non-hex character, and a single
+			// space after it is removed.
+			// Invalid UTF-8 is a RuneError of 1 byte, so use the size it
+			// actually had rather than utf8.RuneLen().
+			_, size := utf8.DecodeRuneInString(s[i:])
+			if n, _ := utf8.DecodeRuneInString(s[i+size:]); n < utf8.RuneSelf && (isHex(byte(n)) || n == ' ' || n == '\t') {
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeSpecial escapes quotes, backslashes, and the like.
+func escapeSpecial(r rune, lang EscapeLang) (string, bool) {
+	switch lang {
+	case EscapeURL:
+		return "", false
+	case EscapeSQL:
+		switch r {
+		case '\'':
+			return "''", true
+		case '\\':
+			return `\\`, true
+		}
+		return "", false
+	case EscapeHTML, EscapeXML:
+		switch r {
+		case '&':
+			return "&amp;", true
+		case '<':
+			return "&lt;", true
+		case '>':
+			return "&gt;", true
+		case '"':
+			return "&quot;", true
+		}
+		return "", false
+	}
+	switch r {
+	case '"':
+		return `\"`, true
+	case '\\':
+		return `\\`, true
+	}
+	return "", false
+}
+
+// mustEscape reports if this codepoint needs to be escaped.
+func mustEscape(r rune, lang EscapeLang, nonASCII bool) bool {
+	if lang == EscapeURL {
+		return !(r < utf8.RuneSelf && (isHex(byte(r)) || (r >= 'g' && r <= 'z') || (r >= 'G' && r <= 'Z') ||
+			r == '-' || r == '.' || r == '_' || r == '~'))
+	}
+	if r < 0x20 || r == 0x7f {
+		return (lang != EscapeHTML && lang != EscapeXML) || (r != '\t' && r != '\n' && r != '\r')
+	}
+	if r < utf8.RuneSelf {
+		return false
+	}
+	if nonASCII {
+		return true
+	}
+	c, _ := Find(r)
+	return c.in(CatControl, CatFormat, CatLineSeparator, CatParagraphSeparator,
+		CatPrivateUse, CatUnassigned, CatSurrogate) ||
+		c.Category() == CatSpaceSeparator || r == utf8.RuneError
+}
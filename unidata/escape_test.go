@@ -0,0 +1,126 @@
+package unidata
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseEscapes(t *testing.T) {
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{``, ``, ``},
+		{`plain text ✓`, `plain text ✓`, ``},
+		{`\u2713`, `✓`, ``},
+		{`\u{2713}\u{1F600}`, `✓😀`, ``},
+		{`\U0001F600`, `😀`, ``},
+		{`\x{2713}`, `✓`, ``},
+		{`\xe2\x9c\x93`, `✓`, ``},
+		{`\x41\xe9`, `Aé`, ``},
+		{`&#x2713;&#10003;&check;`, `✓✓✓`, ``},
+		{`a &amp; b &unknown; &#foo`, `a & b &unknown; &#foo`, ``},
+		{`%E2%9C%93 100%`, `✓ 100%`, ``},
+		{`\N{CHECK MARK}\N{check mark}`, `✓✓`, ``},
+		{`\N{LINE FEED}\N{CJK UNIFIED IDEOGRAPH-4E00}`, "\n一", ``},
+		{`u"café"`, `café`, ``},
+		{`"café"`, `café`, ``},
+		{`r"café"`, `café`, ``},
+		{`\2713 x\2713x`, `✓x✓x`, ``},
+		{`a\tb\n\\\"\0`, "a\tb\n\\\"\x00", ``},
+		{`\ud83d\ude00`, `😀`, ``},
+		{`&#xd83d;&#xde00;`, `😀`, ``},
+		{`\q`, `\q`, ``},
+
+		{`\ud83d`, ``, `unpaired surrogate: U+D83D`},
+		{`\ude00\ud83d`, ``, `unpaired surrogate: U+DE00`},
+		{`\ud83dx`, ``, `unpaired surrogate: U+D83D`},
+		{`\u27`, ``, `invalid escape: "\\u27"`},
+		{`\x{27`, ``, `invalid escape: "\\x{"; no closing }`},
+		{`\x{zz}`, ``, `invalid escape: "\\x{zz}"`},
+		{`\N{NOT A NAME}`, ``, `unknown codepoint name: "NOT A NAME"`},
+		{`\U00110000`, ``, `out of range: U+110000`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have, err := ParseEscapes(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(have) != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", string(have), tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeInvalid(t *testing.T) {
+	for lang := range EscapeLangs {
+		for _, in := range []string{"\xff", "x\xffy", "\xff1", "\xe2\x9c"} {
+			t.Run(lang.String(), func(t *testing.T) {
+				have := Escape(in, lang, false)
+				if have == "" {
+					t.Errorf("empty output for %q", in)
+				}
+				if !utf8.ValidString(have) {
+					t.Errorf("invalid UTF-8 in output for %q: %q", in, have)
+				}
+			})
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in       string
+		lang     EscapeLang
+		nonASCII bool
+		want     string
+	}{
+		{"", EscapeGo, false, ""},
+		{"café ✓", EscapeGo, false, "café ✓"},
+		{"café ✓ 😀", EscapeGo, true, `caf\u00e9 \u2713 \U0001f600`},
+		{"a\u200bb\u00a0c", EscapeGo, false, `a\u200bb\u00a0c`},
+		{"\"q\" \\ \n\x01", EscapeGo, false, `\"q\" \\ \n\x01`},
+		{"é😀", EscapePython, true, `\xe9\U0001f600`},
+		{"é😀", EscapeJS, true, `\u00e9\u{1f600}`},
+		{"é😀", EscapeJSON, true, `\u00e9\ud83d\ude00`},
+		{"é😀", EscapeJava, true, `\u00e9\ud83d\ude00`},
+		{"é😀\x01", EscapeRust, true, `\u{e9}\u{1f600}\x01`},
+		{"é😀\x01\u0085", EscapeC, true, `\u00e9\U0001f600\001\302\205`},
+		{"é1 éx", EscapeCSS, true, `\e9 1 \e9x`},
+		{"it's é😀", EscapeSQL, true, `it''s \00e9\+01f600`},
+		{"a b/é~", EscapeURL, false, `a%20b%2F%C3%A9~`},
+		{"<a & \"b\">\n\u200b", EscapeHTML, false, "&lt;a &amp; &quot;b&quot;&gt;\n&ZeroWidthSpace;"},
+		{"<é>", EscapeXML, true, "&lt;&#xe9;&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have := Escape(tt.in, tt.lang, tt.nonASCII)
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+
+			// Make sure it round-trips; ParseEscapes() doesn't do octal or
+			// SQL's ''.
+			if tt.lang == EscapeC || tt.lang == EscapeSQL {
+				return
+			}
+			back, err := ParseEscapes(have)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(back) != tt.in {
+				t.Errorf("doesn't round-trip\nhave: %q\nwant: %q", string(back), tt.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package unidata
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	regionalA = 0x1f1e6 // REGIONAL INDICATOR SYMBOL LETTER A
+	regionalZ = 0x1f1ff // REGIONAL INDICATOR SYMBOL LETTER Z
+	blackFlag = 0x1f3f4 // WAVING BLACK FLAG
+	tagBase   = 0xe0000 // Tag characters are ASCII + 0xe0000
+	tagCancel = 0xe007f // CANCEL TAG
+)
+
+// Flag gets the flag emoji for an ISO 3166-1 region code such as "NL", or an
+// ISO 3166-2 subdivision code such as "GB-SCT". The codes are
+// case-insensitive, and the "-" is optional for subdivisions.
+//
+// Region codes become a pair of regional indicators. This works for codes that
+// aren't in [Emojis], but these flags aren't "recommended for general
+// interchange" and most systems will display them as the letters.
+//
+// Subdivision codes must be one of the subdivision flags in [Emojis] (England,
+// Scotland, and Wales); it's an error if it's not.
+func Flag(code string) (Emoji, error) {
+	c := strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(code))
+	if len(c) < 2 || len(c) > 5 || !isAlpha(c[:2]) || !isAlnum(c[2:]) {
+		return Emoji{}, fmt.Errorf("unidata.Flag: invalid region or subdivision code: %q", code)
+	}
+
+	var cp []rune
+	if len(c) == 2 {
+		cp = []rune{rune(c[0]-'a') + regionalA, rune(c[1]-'a') + regionalA}
+	} else {
+		cp = make([]rune, 0, len(c)+2)
+		cp = append(cp, blackFlag)
+		for _, r := range c {
+			cp = append(cp, r+tagBase)
+		}
+		cp = append(cp, tagCancel)
+	}
+
+	for _, e := range Emojis {
+		if isEmoji(e, cp...) {
+			return e, nil
+		}
+	}
+	if len(c) > 2 {
+		return Emoji{}, fmt.Errorf("unidata.Flag: no flag for subdivision %q; supported are: %s",
+			code, strings.Join(subdivisionFlags(), ", "))
+	}
+	e := Emoji{Codepoints: cp, group: EmojiFlags, subgroup: EmojiCountryFlag}
+	e.Name = "flag: " + e.Region()
+	return e, nil
+}
+
+// subdivisionFlags gets the region codes for all subdivision flags in Emojis.
+func subdivisionFlags() []string {
+	var codes []string
+	for _, e := range Emojis {
+		if e.subgroup == EmojiSubdivisionFlag {
+			codes = append(codes, e.Region())
+		}
+	}
+	return codes
+}
+
+// Region gets the ISO 3166 region or subdivision code for a flag emoji, such as
+// "NL" or "GB-SCT". This is an empty string if this emoji isn't a region flag.
+func (e Emoji) Region() string {
+	r, n := FlagRegion(e.Codepoints)
+	if n != len(e.Codepoints) {
+		return ""
+	}
+	return r
+}
+
+// FlagRegion decodes the flag at the start of cp, returning the ISO 3166 region
+// or subdivision code and the number of codepoints it uses. The region is empty
+// and n is 0 if cp doesn't start with a flag.
+func FlagRegion(cp []rune) (region string, n int) {
+	if len(cp) >= 2 && isRegional(cp[0]) && isRegional(cp[1]) {
+		return string([]rune{cp[0] - regionalA + 'A', cp[1] - regionalA + 'A'}), 2
+	}
+
+	if len(cp) < 4 || cp[0] != blackFlag {
+		return "", 0
+	}
+	var b strings.Builder
+	for i, r := range cp[1:] {
+		if r == tagCancel {
+			s := b.String()
+			if len(s) < 3 || !isAlpha(s[:2]) {
+				return "", 0
+			}
+			return strings.ToUpper(s[:2] + "-" + s[2:]), i + 2
+		}
+		if r < tagBase+'0' || r > tagBase+'z' || !isAlnum(string(r-tagBase)) || b.Len() >= 5 {
+			return "", 0
+		}
+		b.WriteRune(r - tagBase)
+	}
+	return "", 0
+}
+
+func isRegional(r rune) bool { return r >= regionalA && r <= regionalZ }
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
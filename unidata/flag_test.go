@@ -0,0 +1,63 @@
+package unidata
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlag(t *testing.T) {
+	tests := []struct {
+		in, want, name, wantErr string
+	}{
+		{"NL", "🇳🇱", "flag: Netherlands", ""},
+		{"nl", "🇳🇱", "flag: Netherlands", ""},
+		{"gb-sct", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "flag: Scotland", ""},
+		{"GBSCT", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "flag: Scotland", ""},
+		{"XX", "🇽🇽", "flag: XX", ""},
+		{"gb-wls", "🏴󠁧󠁢󠁷󠁬󠁳󠁿", "flag: Wales", ""},
+
+		{"", "", "", "invalid region"},
+		{"N", "", "", "invalid region"},
+		{"N1", "", "", "invalid region"},
+		{"gb-sctxx", "", "", "invalid region"},
+		{"ñl", "", "", "invalid region"},
+		{"us-ca", "", "", `no flag for subdivision "us-ca"; supported are: GB-ENG, GB-SCT, GB-WLS`},
+		{"gbzzz", "", "", `no flag for subdivision "gbzzz"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have, err := Flag(tt.in)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %v", err, tt.wantErr)
+			}
+			if have.String() != tt.want || have.Name != tt.name {
+				t.Errorf("\nhave: %q %q\nwant: %q %q", have.String(), have.Name, tt.want, tt.name)
+			}
+		})
+	}
+}
+
+func TestFlagRegion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		n    int
+	}{
+		{"", "", 0},
+		{"🇳🇱", "NL", 2},
+		{"🇳🇱🇧🇪", "NL", 2},
+		{"🇳", "", 0},
+		{"🏴󠁧󠁢󠁳󠁣󠁴󠁿x", "GB-SCT", 7},
+		{"🏴", "", 0},
+		{"🏴󠁧󠁢󠁳󠁣󠁴", "", 0},
+		{"🏴‍☠️", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have, n := FlagRegion([]rune(tt.in))
+			if have != tt.want || n != tt.n {
+				t.Errorf("\nhave: %q %d\nwant: %q %d", have, n, tt.want, tt.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+//go:build generate
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"zgo.at/zli"
+)
+
+// Only the confusables that look like printable ASCII are included; that's
+// what's useful for finding spoofed identifiers in source code, and the full
+// list is rather large.
+//
+// This reads Unicode's confusables.txt, or the tables.go that
+// github.com/mtibben/confusables generates from it. The first argument is
+// where the file came from, which is added to the generated comment; this
+// comes first as "go run" would treat tables.go as a source file.
+func main() {
+	if len(os.Args) != 3 {
+		zli.Fatalf("usage: confusables.go [source] [confusables.txt or tables.go]")
+	}
+	data, err := os.ReadFile(os.Args[2])
+	zli.F(err)
+	var (
+		src   = os.Args[1]
+		parse = parseTxt
+	)
+	if strings.HasSuffix(os.Args[2], ".go") {
+		parse = parseTablesGo
+	}
+
+	type conf struct {
+		cp     rune
+		target string
+	}
+	var (
+		confs   []conf
+		version string
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		// "# Version: 13.0.0" in confusables.txt, and "// Version: 13.0.0" in
+		// the copied header in tables.go.
+		if _, v, ok := strings.Cut(line, " Version: "); ok {
+			version = strings.TrimSpace(v)
+		}
+		cp, target, ok := parse(line)
+		if !ok || cp < 0x80 {
+			continue
+		}
+		ascii := true
+		for _, r := range target {
+			if r < 0x20 || r > 0x7e {
+				ascii = false
+				break
+			}
+		}
+		if ascii {
+			confs = append(confs, conf{cp, string(target)})
+		}
+	}
+	slices.SortFunc(confs, func(a, b conf) int { return int(a.cp - b.cp) })
+
+	fmt.Print("// Code generated by gen.zsh; DO NOT EDIT\n\npackage unidata\n\n")
+	fmt.Print("// Codepoints that can be confused with ASCII text.\n//\n")
+	fmt.Printf("// From: %s\n// Unicode version: %s\n", src, version)
+	fmt.Print("var confusables = map[rune]string{\n")
+	for _, c := range confs {
+		fmt.Printf("\t0x%04X: %q,\n", c.cp, c.target)
+	}
+	fmt.Print("}\n")
+}
+
+// parseTxt parses a line from confusables.txt:
+//
+//	05AD ;	0596 ;	MA	# ( ֭ → ֖ ) HEBREW ACCENT DEHI → HEBREW ACCENT TIPEHA	#
+func parseTxt(line string) (rune, []rune, bool) {
+	line, _, _ = strings.Cut(line, "#")
+	f := strings.Split(line, ";")
+	if len(f) < 2 {
+		return 0, nil, false
+	}
+	cp, err := strconv.ParseUint(strings.TrimSpace(f[0]), 16, 32)
+	zli.F(err)
+	var target []rune
+	for _, t := range strings.Fields(f[1]) {
+		r, err := strconv.ParseUint(t, 16, 32)
+		zli.F(err)
+		target = append(target, rune(r))
+	}
+	return rune(cp), target, true
+}
+
+// parseTablesGo parses a line from mtibben/confusables' tables.go:
+//
+//	0x000005AD: "\u0596",
+func parseTablesGo(line string) (rune, []rune, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "0x") || !strings.HasSuffix(line, ",") {
+		return 0, nil, false
+	}
+	k, v, ok := strings.Cut(line[:len(line)-1], ": ")
+	if !ok {
+		zli.Fatalf("unexpected line: %q", line)
+	}
+	cp, err := strconv.ParseUint(k[2:], 16, 32)
+	zli.F(err)
+	target, err := strconv.Unquote(v)
+	zli.F(err)
+	return rune(cp), []rune(target), true
+}
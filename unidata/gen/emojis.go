@@ -3,6 +3,8 @@
 package main
 
 import (
+	"cmp"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"os"
@@ -22,6 +24,14 @@ const (
 	GenderRole = 2
 )
 
+// Keep in sync with unidata/emoji.go
+var statuses = map[string]int{
+	"fully-qualified":     0,
+	"minimally-qualified": 1,
+	"unqualified":         2,
+	"component":           3,
+}
+
 type (
 	EmojiGroup    uint8
 	EmojiSubgroup uint16
@@ -33,6 +43,10 @@ type (
 		CLDR       []string
 		SkinTones  bool
 		Genders    int
+		Hair       bool
+		Direction  bool
+		Version    string
+		Status     int
 	}
 )
 
@@ -63,15 +77,148 @@ func readCLDR(f string) map[string][]string {
 	return out
 }
 
+// shortcodeKey gets the key for the shortcode maps: the codepoints without ZWJ
+// and VS16, to match them up with the codepoints from emoji-test.txt.
+func shortcodeKey(cps []rune) string {
+	key := make([]rune, 0, len(cps))
+	for _, r := range cps {
+		if r != 0x200d && r != 0xfe0f {
+			key = append(key, r)
+		}
+	}
+	return string(key)
+}
+
+// readGitHub reads GitHub's shortcodes (from gemoji) from the github.json that
+// goldmark-emoji generates, which is in the form of:
+//
+//	{"data": [{"Name": "thumbs up", "ShortNames": ["+1", "thumbsup"], "Unicode": ["128077"]}]}
+func readGitHub(f string) map[string][]string {
+	d, err := os.ReadFile(f)
+	zli.F(err)
+
+	var gh struct {
+		Data []struct {
+			ShortNames []string
+			Unicode    []string
+		}
+	}
+	zli.F(json.Unmarshal(d, &gh))
+
+	out := make(map[string][]string, len(gh.Data))
+	for _, e := range gh.Data {
+		cps := make([]rune, 0, len(e.Unicode))
+		for _, u := range e.Unicode {
+			r, err := strconv.ParseInt(u, 10, 32)
+			zli.F(err)
+			cps = append(cps, rune(r))
+		}
+		key := shortcodeKey(cps)
+		out[key] = append(out[key], e.ShortNames...)
+	}
+	return out
+}
+
+// readSlack reads Slack's shortcodes (from iamcal's emoji-data) from
+// Mattermost's emoji_data.go, which uses the same names:
+//
+//	"+1": "1f44d",
+//	"thumbsup": "1f44d",
+//	"+1_light_skin_tone": "1f44d-1f3fb",
+//
+// Names with a skin tone are Mattermost's; Slack adds the tone as a separate
+// ":skin-tone-2:", so they're skipped.
+func readSlack(f string) map[string][]string {
+	d, err := os.ReadFile(f)
+	zli.F(err)
+
+	out := make(map[string][]string)
+	for _, line := range strings.Split(string(d), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `"`) || !strings.HasSuffix(line, `",`) {
+			continue
+		}
+		name, hex, ok := strings.Cut(line[:len(line)-1], ":")
+		if !ok {
+			zli.Fatalf("unexpected line in %q: %q", f, line)
+		}
+		name, err := strconv.Unquote(strings.TrimSpace(name))
+		zli.F(err)
+		hex, err = strconv.Unquote(strings.TrimSpace(hex))
+		zli.F(err)
+
+		var (
+			cps  []rune
+			tone bool
+		)
+		for _, h := range strings.Split(hex, "-") {
+			r, err := strconv.ParseInt(h, 16, 32)
+			if err != nil { // Mattermost's own emojis.
+				cps = nil
+				break
+			}
+			cps, tone = append(cps, rune(r)), tone || (r >= 0x1f3fb && r <= 0x1f3ff)
+		}
+		if len(cps) == 0 || (tone && len(cps) > 1) {
+			continue
+		}
+		key := shortcodeKey(cps)
+		out[key] = append(out[key], name)
+	}
+	return out
+}
+
+// readDiscord reads the shortcodes from Discord's emoji list, as extracted by
+// discordemojimap in mapping.go:
+//
+//	"thumbsup": "\U0001f44d",
+//	"+1": "\U0001f44d",
+//
+// The first name for an emoji is the one Discord shows.
+func readDiscord(f string) map[string][]string {
+	d, err := os.ReadFile(f)
+	zli.F(err)
+
+	out := make(map[string][]string)
+	for _, line := range strings.Split(string(d), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `"`) || !strings.HasSuffix(line, `",`) {
+			continue
+		}
+		name, emoji, ok := strings.Cut(line[:len(line)-1], ": ")
+		if !ok {
+			zli.Fatalf("unexpected line in %q: %q", f, line)
+		}
+		name, err := strconv.Unquote(name)
+		zli.F(err)
+		emoji, err = strconv.Unquote(emoji)
+		zli.F(err)
+
+		key := shortcodeKey([]rune(emoji))
+		out[key] = append(out[key], name)
+	}
+	return out
+}
+
 func main() {
-	if len(os.Args) != 3 {
-		zli.Fatalf("usage: emojis.go [emoji-test.txt] [cldr-en.xml]")
+	if len(os.Args) != 6 {
+		zli.Fatalf("usage: emojis.go [emoji-test.txt] [cldr-en.xml] [goldmark-emoji-github.json] [mattermost-emoji_data.go] [discord-mapping.go]")
 	}
 
 	cldr := readCLDR(os.Args[2])
 	text, err := os.ReadFile(os.Args[1])
 	zli.F(err)
 
+	/// GitHub uses gemoji and Slack uses iamcal's emoji-data. Discord uses its
+	/// own names, which are mostly but not entirely the same as the JoyPixels
+	/// (formerly EmojiOne) shortcodes.
+	var (
+		scGitHub  = readGitHub(os.Args[3])
+		scSlack   = readSlack(os.Args[4])
+		scDiscord = readDiscord(os.Args[5])
+		fq        []string
+	)
+
 	var (
 		emo             = make([]Emoji, 0, 2048)
 		signGender      = make(map[rune]struct{})
@@ -80,14 +227,54 @@ func main() {
 		subgroups       = make(map[string][]string)
 		groupID         EmojiGroup
 		subgroupID      EmojiSubgroup
+		versions        []string
+		toned           = make(map[string]struct{})
+		hair            = make(map[string]struct{})
+		fqKeys          = make(map[string]struct{})
+		direction       = make(map[string]struct{})
 		lines           = strings.Split(string(text), "\n")
 	)
 	lines = slices.DeleteFunc(lines, func(l string) bool {
-		return !(strings.Contains(l, "group:") || strings.Contains(l, "subgroup:") || strings.Contains(l, "; fully-qualified"))
+		return !(strings.Contains(l, "group:") || strings.Contains(l, "subgroup:") || strings.Contains(l, ";"))
 	})
-	lines = append(lines, "") /// So we don't need to check len for lines[i+1] below.
 
-	for i, line := range lines {
+	/// Record which sequences have a skin tone variant; the skin tone variants
+	/// are listed after the base emoji, but not always directly after it as
+	/// the minimally-qualified and unqualified variants are listed as well.
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.ContainsAny(line, "\U0001f3fb\U0001f3fc\U0001f3fd\U0001f3fe\U0001f3ff") {
+			continue
+		}
+		cp := parseCodepoints(line)
+		if len(cp) > 1 {
+			toned[toneKey(cp)] = struct{}{}
+		}
+	}
+
+	/// Same for the hair styles and facing right variants, which are added to
+	/// the base emoji:
+	///
+	///   1F9D1 1F3FB 200D 1F9B0                # 🧑🏻‍🦰 E12.1 person: light skin tone, red hair
+	///   1F6B6 200D 2640 FE0F 200D 27A1 FE0F   # 🚶‍♀️‍➡️ E15.1 woman walking facing right
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") || !strings.Contains(line, "fully-qualified") {
+			continue
+		}
+		cp := parseCodepoints(line)
+		fqKeys[toneKey(cp)] = struct{}{}
+		if isHair(cp) {
+			hair[toneKey(cp[:len(cp)-1])] = struct{}{}
+		}
+		if isDirection(cp) {
+			cp = slices.DeleteFunc(cp[:slices.Index(cp, 0x27a1)], func(r rune) bool { return r == 0x2640 || r == 0x2642 })
+			direction[toneKey(cp)] = struct{}{}
+		}
+	}
+
+	for _, line := range lines {
 		/// Groups are listed as a comment, but we want to preserve them.
 		///   # group: Smileys & Emotion
 		///   # subgroup: face-smiling
@@ -114,30 +301,43 @@ func main() {
 
 		/// "only fully-qualified emoji zwj sequences should be generated by
 		/// keyboards and other user input devices"
-		if !strings.HasSuffix(line, "; fully-qualified") {
-			continue
+		///
+		/// We still include the other ones, so it's possible to query for
+		/// them, but they're excluded from the default output.
+		status, ok := statuses[strings.TrimSpace(strings.Split(line, ";")[1])]
+		if !ok {
+			zli.Fatalf("unknown status in line %q", line)
 		}
 
-		codepoints := func() []rune {
-			s := strings.Split(strings.TrimSpace(strings.Split(line, ";")[0]), " ")
-			all := make([]rune, 0, len(s))
-			for _, c := range s {
-				r, err := strconv.ParseInt(string(c), 16, 32)
-				zli.F(err)
-				if r != 0x200d { /// Skip ZWJ; we construct it ourself.
-					all = append(all, rune(r))
-				}
-			}
-			return all
-		}()
+		/// Record all fully-qualified sequences, including the skin tone and
+		/// gender variants, to add the shortcodes for.
+		if status == statuses["fully-qualified"] {
+			fq = append(fq, line)
+		}
+
+		/// 1F600 ; fully-qualified # 😀 E1.0 grinning face
+		///                              ^^^^
+		ver := strings.SplitN(comment, " ", 3)[1][1:]
+		if !slices.Contains(versions, ver) {
+			versions = append(versions, ver)
+		}
+
+		codepoints := parseCodepoints(line)
+
+		/// Skin tones; we just want the base emojis here; we detect skin tones
+		/// later. The skin tone components themselves are included.
+		if status != statuses["component"] && zslice.ContainsAny(codepoints, 0x1f3fb, 0x1f3fc, 0x1f3fd, 0x1f3fe, 0x1f3ff) {
+			continue
+		}
 
-		/// Skin tones; we just want the base emojis here; we detect skin tones later.
-		if zslice.ContainsAny(codepoints, 0x1f3fb, 0x1f3fc, 0x1f3fd, 0x1f3fe, 0x1f3ff) {
+		/// Hair style and facing right variants; added to the base emoji.
+		if status != statuses["component"] && (isHair(codepoints) || isDirection(codepoints)) {
 			continue
 		}
 
-		/// Male/female sign; store that we saw this.
-		if len(codepoints) > 1 && zslice.ContainsAny(codepoints, 0x2640, 0x2642) {
+		/// Male/female sign; store that we saw this. The female and male signs
+		/// themselves are included.
+		if len(codepoints) > 1 && zslice.ContainsAny(codepoints[1:], 0x2640, 0x2642) {
 			signGender[codepoints[0]] = struct{}{}
 			continue
 		}
@@ -158,8 +358,12 @@ func main() {
 			continue
 		}
 
-		tone := strings.ContainsAny(lines[i+1], "\U0001f3fb\U0001f3fc\U0001f3fd\U0001f3fe\U0001f3ff")
-		gender := GenderNone
+		var (
+			_, tone    = toned[toneKey(codepoints)]
+			_, hasHair = hair[toneKey(codepoints)]
+			_, hasDir  = direction[toneKey(codepoints)]
+			gender     = GenderNone
+		)
 
 		// Old/classic gendered emoji. A "person" emoji is combined with "female
 		// sign" or "male sign" to make an explicitly gendered one:
@@ -189,8 +393,13 @@ func main() {
 		//
 		// Detect: These only appear in the person-role and person-activity
 		// subgroups; the special cases only in family subgroup.
+		//
+		// Not all of them have gendered variants, such as 🧑‍🎄 (Mx Claus) or
+		// 🧑‍🤝‍🧑 (people holding hands), so check if the "man" variant exists.
 		if codepoints[0] == 0x1f9d1 {
-			gender = GenderRole
+			if _, ok := fqKeys[toneKey(append([]rune{0x1f468}, codepoints[1:]...))]; ok {
+				gender = GenderRole
+			}
 		}
 
 		emo = append(emo, Emoji{
@@ -200,12 +409,19 @@ func main() {
 			Subgroup:   subgroupID - 1,
 			SkinTones:  tone,
 			Genders:    gender,
+			Hair:       hasHair,
+			Direction:  hasDir,
 			CLDR:       cldr[strings.ReplaceAll(strings.ReplaceAll(string(codepoints), "\ufe0f", ""), "\ufe0e", "")],
+			Version:    ver,
+			Status:     status,
 		})
 	}
 
 	// Add genders indicated by male/female sign.
 	for i, e := range emo {
+		if e.Status == statuses["component"] {
+			continue
+		}
 		if len(e.Codepoints) == 1 || (len(e.Codepoints) == 2 && e.Codepoints[1] == 0xfe0f) {
 			_, ok := signGender[e.Codepoints[0]]
 			if ok {
@@ -270,6 +486,29 @@ func main() {
 		}
 		fmt.Print("}\n\n")
 	}
+	{ // Write versions.
+		slices.SortFunc(versions, func(a, b string) int {
+			am, an, _ := strings.Cut(a, ".")
+			bm, bn, _ := strings.Cut(b, ".")
+			if c := cmp.Compare(atoi(am), atoi(bm)); c != 0 {
+				return c
+			}
+			return cmp.Compare(atoi(an), atoi(bn))
+		})
+		fmt.Println("// Emoji versions.\nconst (")
+		fmt.Printf("\t%s = EmojiVersion(iota)\n", mkversion(versions[0]))
+		for _, v := range versions[1:] {
+			fmt.Printf("\t%s\n", mkversion(v))
+		}
+		fmt.Print(")\n\n")
+
+		fmt.Println("// EmojiVersions is a list of all emoji versions.")
+		fmt.Println("var EmojiVersions = map[EmojiVersion]string{")
+		for _, v := range versions {
+			fmt.Printf("\t%s: %q,\n", mkversion(v), v)
+		}
+		fmt.Print("}\n\n")
+	}
 	{ // Write emojis
 		fmt.Println("var Emojis = []Emoji{")
 		for _, e := range emo {
@@ -279,14 +518,104 @@ func main() {
 			}
 			cp = cp[:len(cp)-2]
 
-			///                   CP   Name Grp Sgr CLDR sk  gnd
-			fmt.Printf("\t{[]rune{%s}, %q,  %d, %d, %#v, %t, %d},\n",
-				cp, e.Name, e.Group, e.Subgroup, e.CLDR, e.SkinTones, e.Genders)
+			///                   CP   Name Grp Sgr CLDR sk  gnd hair dir ver st
+			fmt.Printf("\t{[]rune{%s}, %q,  %d, %d, %#v, %t, %d, %t, %t, %s, %d},\n",
+				cp, e.Name, e.Group, e.Subgroup, e.CLDR, e.SkinTones, e.Genders,
+				e.Hair, e.Direction, mkversion(e.Version), e.Status)
+		}
+		fmt.Print("}\n\n")
+	}
+	{ // Write shortcodes
+		fmt.Println("var emojiShortcodes = map[string]shortcodes{")
+		for _, line := range fq {
+			var (
+				cp  = parseCodepoints(line)
+				key = strings.ReplaceAll(string(cp), "\ufe0f", "")
+				gh  = scGitHub[key]
+				sl  = scSlack[key]
+				ds  = scDiscord[key]
+			)
+			if len(gh) == 0 && len(sl) == 0 && len(ds) == 0 {
+				continue
+			}
+
+			/// Use the sequence as it appears in emoji-test.txt, including ZWJ.
+			fmt.Printf("\t%+q: {", sequence(line))
+			if len(gh) > 0 {
+				fmt.Printf("github: %#v, ", gh)
+			}
+			if len(sl) > 0 {
+				fmt.Printf("slack: %#v, ", sl)
+			}
+			if len(ds) > 0 {
+				fmt.Printf("discord: %#v, ", ds)
+			}
+			fmt.Println("},")
+		}
+		fmt.Print("}\n\n")
+	}
+	{ // Write RGI sequences.
+		fmt.Println("var rgi = map[string]struct{}{")
+		for _, line := range fq {
+			fmt.Printf("\t%+q: {},\n", sequence(line))
 		}
 		fmt.Print("}\n\n")
 	}
 }
 
+// sequence gets the sequence as it appears in emoji-test.txt, including the
+// ZWJ.
+func sequence(line string) string {
+	var seq string
+	for _, c := range strings.Fields(strings.Split(line, ";")[0]) {
+		r, err := strconv.ParseInt(c, 16, 32)
+		zli.F(err)
+		seq += string(rune(r))
+	}
+	return seq
+}
+
+func parseCodepoints(line string) []rune {
+	s := strings.Fields(strings.Split(line, ";")[0])
+	all := make([]rune, 0, len(s))
+	for _, c := range s {
+		r, err := strconv.ParseInt(string(c), 16, 32)
+		zli.F(err)
+		if r != 0x200d { /// Skip ZWJ; we construct it ourself.
+			all = append(all, rune(r))
+		}
+	}
+	return all
+}
+
+// toneKey gets a key to match skin tone variants on: the codepoints without
+// any skin tone modifiers and variation selectors.
+func toneKey(cp []rune) string {
+	return string(slices.DeleteFunc(slices.Clone(cp), func(r rune) bool {
+		return r == 0xfe0f || (r >= 0x1f3fb && r <= 0x1f3ff)
+	}))
+}
+
+// isHair reports if this is a hair style variant, such as "person: red hair".
+func isHair(cp []rune) bool {
+	l := cp[len(cp)-1]
+	return len(cp) > 1 && l >= 0x1f9b0 && l <= 0x1f9b3
+}
+
+// isDirection reports if this is a facing right variant, such as "person
+// walking facing right".
+func isDirection(cp []rune) bool {
+	return len(cp) > 1 && slices.Index(cp, 0x27a1) > 0
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	zli.F(err)
+	return n
+}
+
+func mkversion(v string) string { return "Emoji" + strings.ReplaceAll(v, ".", "_") }
+
 func mkconst(n string) string {
 	dash := zstring.IndexAll(n, "-")
 	for i := len(dash) - 1; i >= 0; i-- {
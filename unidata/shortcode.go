@@ -0,0 +1,279 @@
+package unidata
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type (
+	// ShortcodeSet is a set of emoji shortcodes, such as :tada:. Every
+	// platform uses its own set, which are mostly but not entirely identical.
+	ShortcodeSet uint8
+
+	shortcodes struct {
+		github, slack, discord []string
+	}
+)
+
+// Shortcode sets.
+const (
+	ShortcodeGitHub  = ShortcodeSet(iota) // GitHub; from gemoji.
+	ShortcodeSlack                        // Slack; from iamcal's emoji-data.
+	ShortcodeDiscord                      // Discord; from Discord's emoji list.
+	ShortcodeCLDR                         // Derived from the CLDR short name.
+)
+
+// ShortcodeSets is a list of all shortcode sets.
+var ShortcodeSets = map[ShortcodeSet]string{
+	ShortcodeGitHub:  "github",
+	ShortcodeSlack:   "slack",
+	ShortcodeDiscord: "discord",
+	ShortcodeCLDR:    "cldr",
+}
+
+func (s ShortcodeSet) String() string { return ShortcodeSets[s] }
+
+// FindShortcodeSet finds a shortcode set by name.
+func FindShortcodeSet(name string) (ShortcodeSet, bool) {
+	name = strings.ToLower(name)
+	for k, v := range ShortcodeSets {
+		if v == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+var (
+	shortcodeOnce  sync.Once
+	shortcodeIndex map[string]shortcodes // Emoji without ZWJ and VS16 → shortcodes.
+	cldrIndex      map[string]string     // Emoji without ZWJ and VS16 → CLDR shortcode.
+	shortcodeRev   [4]map[string]string  // Shortcode → emoji, per set.
+	shortcodeMax   int                   // Longest emoji in the indexes, in runes.
+
+	reShortcode   = regexp.MustCompile(`:[a-zA-Z0-9_+\-]+:(?::skin-tone-[2-6]:)?`)
+	reSkinTone    = regexp.MustCompile(`::skin-tone-([2-6])$`)
+	reCLDRNonWord = regexp.MustCompile(`[^a-z0-9]+`)
+	cldrReplace   = strings.NewReplacer("&", "and", "#", "hash", "*", "asterisk", "\u2019", "", "'", "", ".", "")
+	cldrTranslit  = strings.NewReplacer("á", "a", "å", "a", "ã", "a", "ç", "c", "é", "e", "í", "i", "ñ", "n", "ô", "o", "ü", "u")
+	removeJoiners = strings.NewReplacer("\u200d", "", "\ufe0f", "")
+)
+
+// normEmoji removes the ZWJ and VS16 from an emoji sequence, so that it can be
+// matched regardless of how it was written.
+func normEmoji(s string) string { return removeJoiners.Replace(s) }
+
+// Build the lookup tables; this is done on first use so it doesn't slow down
+// the startup time for people who never use it.
+func buildShortcodes() {
+	shortcodeIndex = make(map[string]shortcodes, len(emojiShortcodes))
+	cldrIndex = make(map[string]string, len(Emojis))
+	for i := range shortcodeRev {
+		shortcodeRev[i] = make(map[string]string)
+	}
+
+	add := func(set ShortcodeSet, codes []string, emoji string) {
+		for _, c := range codes {
+			if _, ok := shortcodeRev[set][c]; !ok {
+				shortcodeRev[set][c] = emoji
+			}
+		}
+	}
+	setMax := func(n string) {
+		if l := utf8.RuneCountInString(n); l > shortcodeMax {
+			shortcodeMax = l
+		}
+	}
+	for e, sc := range emojiShortcodes {
+		n := normEmoji(e)
+		shortcodeIndex[n] = sc
+		setMax(n)
+		add(ShortcodeGitHub, sc.github, e)
+		add(ShortcodeSlack, sc.slack, e)
+		add(ShortcodeDiscord, sc.discord, e)
+	}
+
+	// The CLDR names are derived from the emoji name, so include all skin tone
+	// and gender variants as well.
+	for _, e := range Emojis {
+		if e.status != StatusFullyQualified {
+			continue
+		}
+		variants, _ := e.variants()
+		for _, v := range variants {
+			var (
+				sc = cldrShortcode(v.Name)
+				n  = normEmoji(string(v.Codepoints))
+			)
+			cldrIndex[n] = sc
+			setMax(n)
+			add(ShortcodeCLDR, []string{sc}, v.String())
+		}
+	}
+}
+
+// cldrShortcode creates a shortcode from the CLDR short name, for example
+// "flag: Côte d’Ivoire" becomes "flag_cote_divoire".
+func cldrShortcode(name string) string {
+	name = cldrTranslit.Replace(cldrReplace.Replace(strings.ToLower(name)))
+	return strings.Trim(reCLDRNonWord.ReplaceAllString(name, "_"), "_")
+}
+
+// Shortcodes gets the shortcodes for this emoji in the given set, without the
+// surrounding colons. This may be empty if the set has no shortcode for this
+// emoji.
+//
+// Slack doesn't list shortcodes for skin tone variants, but uses the
+// shortcode for the base emoji followed by ":skin-tone-[2-6]:". This will be
+// returned as "+1::skin-tone-2", so that adding the colons gives the correct
+// result.
+func (e Emoji) Shortcodes(set ShortcodeSet) []string {
+	if set == ShortcodeCLDR {
+		return []string{cldrShortcode(e.Name)}
+	}
+
+	shortcodeOnce.Do(buildShortcodes)
+	n := normEmoji(string(e.Codepoints))
+	if sc := shortcodeIndex[n].get(set); len(sc) > 0 || set != ShortcodeSlack {
+		return sc
+	}
+
+	var (
+		tone rune
+		base = make([]rune, 0, len(e.Codepoints))
+	)
+	for _, r := range n {
+		if r >= 0x1f3fb && r <= 0x1f3ff {
+			tone = r
+			continue
+		}
+		base = append(base, r)
+	}
+	if tone == 0 {
+		return nil
+	}
+	sc := shortcodeIndex[string(base)].get(set)
+	tones := make([]string, 0, len(sc))
+	for _, s := range sc {
+		tones = append(tones, s+"::skin-tone-"+strconv.Itoa(int(tone-0x1f3fb+2)))
+	}
+	return tones
+}
+
+func (s shortcodes) get(set ShortcodeSet) []string {
+	switch set {
+	case ShortcodeGitHub:
+		return s.github
+	case ShortcodeSlack:
+		return s.slack
+	case ShortcodeDiscord:
+		return s.discord
+	}
+	return nil
+}
+
+// FromShortcode gets the emoji for a shortcode, looking in all the given sets
+// in order. All sets are used if sets is empty.
+//
+// The shortcode may be given with or without the surrounding colons.
+func FromShortcode(code string, sets ...ShortcodeSet) (string, bool) {
+	shortcodeOnce.Do(buildShortcodes)
+	if len(sets) == 0 {
+		sets = []ShortcodeSet{ShortcodeGitHub, ShortcodeSlack, ShortcodeDiscord, ShortcodeCLDR}
+	}
+	code = strings.TrimSuffix(strings.TrimPrefix(code, ":"), ":")
+
+	var tone EmojiModifier
+	if m := reSkinTone.FindStringSubmatch(code); m != nil {
+		code, tone = code[:len(code)-len(m[0])], ModLight<<(m[1][0]-'2')
+	}
+	for _, s := range sets {
+		e, ok := shortcodeRev[s][code]
+		if !ok {
+			e, ok = shortcodeRev[s][strings.ToLower(code)]
+		}
+		if ok {
+			// Emojis that don't support skin tones ignore it, just like Slack.
+			if base, mods, ok := ParseEmoji(e); tone > 0 && ok && base.Skintones() {
+				e = base.With(mods&^ModAllTones | tone).String()
+			}
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// Emojify replaces all shortcodes such as :tada: in the text with the emoji,
+// looking in all the given sets in order. All sets are used if sets is empty.
+//
+// Shortcodes that aren't found are left as-is.
+func Emojify(text string, sets ...ShortcodeSet) string {
+	return reShortcode.ReplaceAllStringFunc(text, func(m string) string {
+		if e, ok := FromShortcode(m[1:len(m)-1], sets...); ok {
+			return e
+		}
+		return m
+	})
+}
+
+// Demojize replaces all emojis in the text with a shortcode, using the first
+// set that has a shortcode for the emoji. All sets are used if sets is empty.
+//
+// Emojis without a shortcode are left as-is.
+func Demojize(text string, sets ...ShortcodeSet) string {
+	shortcodeOnce.Do(buildShortcodes)
+	if len(sets) == 0 {
+		sets = []ShortcodeSet{ShortcodeGitHub, ShortcodeSlack, ShortcodeDiscord, ShortcodeCLDR}
+	}
+
+	var (
+		b = new(strings.Builder)
+		r = []rune(text)
+	)
+	b.Grow(len(text))
+	for i := 0; i < len(r); i++ {
+		// Find the longest sequence starting here, ignoring ZWJ and VS16.
+		var (
+			sc  string
+			end = -1
+			n   = make([]rune, 0, shortcodeMax)
+		)
+		for j := i; j < len(r) && len(n) < shortcodeMax+1; j++ {
+			if r[j] == 0xfe0f || r[j] == 0x200d {
+				if end == j-1 {
+					end = j
+				}
+				continue
+			}
+			n = append(n, r[j])
+			if c := demojizeOne(string(n), sets); c != "" {
+				sc, end = c, j
+			}
+		}
+		if sc == "" {
+			b.WriteRune(r[i])
+			continue
+		}
+		b.WriteString(":" + sc + ":")
+		i = end
+	}
+	return b.String()
+}
+
+func demojizeOne(n string, sets []ShortcodeSet) string {
+	for _, s := range sets {
+		if s == ShortcodeCLDR {
+			if sc, ok := cldrIndex[n]; ok {
+				return sc
+			}
+			continue
+		}
+		if sc := (Emoji{Codepoints: []rune(n)}).Shortcodes(s); len(sc) > 0 {
+			return sc[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package unidata
+
+import "testing"
+
+func TestShortcode(t *testing.T) {
+	tests := []struct {
+		code string
+		sets []ShortcodeSet
+		want string
+	}{
+		{":tada:", nil, "🎉"},
+		{"tada", nil, "🎉"},
+		{":+1:", []ShortcodeSet{ShortcodeGitHub}, "👍"},
+		{":thumbs_up:", []ShortcodeSet{ShortcodeCLDR}, "👍"},
+		{":thumbs_up_dark_skin_tone:", []ShortcodeSet{ShortcodeCLDR}, "👍🏿"},
+		{":flag_cote_divoire:", []ShortcodeSet{ShortcodeCLDR}, "🇨🇮"},
+		{":tada:", []ShortcodeSet{ShortcodeCLDR}, ""},
+		{":not-an-emoji:", nil, ""},
+		{":woman-shrugging:", []ShortcodeSet{ShortcodeSlack}, "🤷‍♀️"},
+		{":+1::skin-tone-6:", []ShortcodeSet{ShortcodeSlack}, "👍🏿"},
+		{":woman-shrugging::skin-tone-6:", []ShortcodeSet{ShortcodeSlack}, "🤷🏿‍♀️"},
+		{":female-firefighter::skin-tone-2:", []ShortcodeSet{ShortcodeSlack}, "👩🏻‍🚒"},
+		{":tada::skin-tone-6:", []ShortcodeSet{ShortcodeSlack}, "🎉"},
+		{":woman-shrugging:", []ShortcodeSet{ShortcodeGitHub}, ""},
+		{":thumbsup_tone5:", []ShortcodeSet{ShortcodeDiscord}, "👍🏿"},
+		{":flag_gb:", []ShortcodeSet{ShortcodeDiscord}, "🇬🇧"},
+		{":england:", []ShortcodeSet{ShortcodeDiscord}, "🏴󠁧󠁢󠁥󠁮󠁧󠁿"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			have, _ := FromShortcode(tt.code, tt.sets...)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmojify(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{":tada: done :+1:", "🎉 done 👍"},
+		{"at 10:30:45 :unknown:", "at 10:30:45 :unknown:"},
+		{"::tada::", ":🎉:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have := Emojify(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+// Every shortcode should give back the same emoji when converted back.
+func TestShortcodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		set   ShortcodeSet
+		emoji string
+		want  string
+	}{
+		{ShortcodeSlack, "🎉", "tada"},
+		{ShortcodeSlack, "👍🏽", "+1::skin-tone-4"},
+		{ShortcodeSlack, "🤷🏽‍♀️", "woman-shrugging::skin-tone-4"},
+		{ShortcodeSlack, "🇳🇱", "flag-nl"},
+		{ShortcodeDiscord, "🎉", "tada"},
+		{ShortcodeDiscord, "🤷🏽‍♀️", "woman_shrugging_tone3"},
+		{ShortcodeDiscord, "🇳🇱", "flag_nl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.set.String()+" "+tt.want, func(t *testing.T) {
+			have := Demojize(tt.emoji, tt.set)
+			if have != ":"+tt.want+":" {
+				t.Fatalf("Demojize\nhave: %q\nwant: %q", have, ":"+tt.want+":")
+			}
+			back, ok := FromShortcode(have, tt.set)
+			if !ok || back != tt.emoji {
+				t.Errorf("FromShortcode\nhave: %q %+q\nwant: %q %+q", back, back, tt.emoji, tt.emoji)
+			}
+		})
+	}
+}
+
+func TestDemojize(t *testing.T) {
+	tests := []struct {
+		in   string
+		sets []ShortcodeSet
+		want string
+	}{
+		{"", nil, ""},
+		{"🎉 done 👍", nil, ":tada: done :+1:"},
+		{"🎉 done 👍", []ShortcodeSet{ShortcodeCLDR}, ":party_popper: done :thumbs_up:"},
+		{"👍🏿", []ShortcodeSet{ShortcodeCLDR}, ":thumbs_up_dark_skin_tone:"},
+		{"❤️x", nil, ":heart:x"},
+		{"❤x", nil, ":heart:x"},
+		{"👨‍👩‍👧", nil, ":family_man_woman_girl:"},
+		{"a✓b", nil, "a✓b"},
+		{"🤷‍♀️ 👍🏿", []ShortcodeSet{ShortcodeSlack}, ":woman-shrugging: :+1::skin-tone-6:"},
+		{"🤷‍♀️ 👍🏿", []ShortcodeSet{ShortcodeDiscord}, ":woman_shrugging: :thumbsup_tone5:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have := Demojize(tt.in, tt.sets...)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
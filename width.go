@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"zgo.at/runewidth"
+	"zgo.at/uni/v2/unidata"
+	"zgo.at/zli"
+)
+
+// Engines to calculate the display width of text; these are the column names
+// for the width command.
+var widthEngines = []struct {
+	name  string
+	width func(string) int
+}{
+	// Sum of runewidth.RuneWidth() for every codepoint; this is what
+	// Codepoint.Cells() uses.
+	{"runewidth", func(s string) int {
+		w := 0
+		for _, r := range s {
+			w += runewidth.RuneWidth(r)
+		}
+		return w
+	}},
+	// Width of every grapheme cluster, which takes emoji sequences,
+	// variation selectors, etc. into account.
+	{"uniseg", uniseg.StringWidth},
+	// Sum of the East Asian Width of every codepoint, like wcwidth().
+	{"eaw", func(s string) int {
+		w := 0
+		for _, r := range s {
+			w += eawWidth(r)
+		}
+		return w
+	}},
+}
+
+// eawWidth gets the width of r from the East Asian Width property: 2 for wide
+// and fullwidth (and ambiguous with -ambiguous wide), 0 for control
+// characters, combining marks, and format characters, and 1 for everything
+// else.
+func eawWidth(r rune) int {
+	info, ok := unidata.Find(r)
+	if !ok {
+		return 1
+	}
+	switch info.Category() {
+	case unidata.CatCc, unidata.CatMn, unidata.CatMe, unidata.CatCf:
+		return 0
+	}
+	if info.IsWide() {
+		return 2
+	}
+	return 1
+}
+
+// width prints the display width of every line or grapheme cluster in the
+// text, as calculated by every engine in widthEngines. If diff is set only the
+// text for which the engines disagree is printed.
+func width(args []string, by, format string, formatSet, diff bool, as printAs) error {
+	if as == printAsTable || as == printAsTableCompact {
+		zli.Fatalf("can't use -as table with the width command")
+	}
+
+	byGrapheme := false
+	switch by {
+	case "", "line":
+	case "grapheme":
+		byGrapheme = true
+	default:
+		return fmt.Errorf("-by: must be line or grapheme for width, not %q", by)
+	}
+
+	cols := []string{"text", "cpoints"}
+	if !formatSet {
+		format = ""
+		for _, e := range widthEngines {
+			format += "%(" + e.name + " r:auto)  "
+		}
+		format += "%(text q)"
+		if byGrapheme {
+			format += "  %(cpoints)"
+		}
+	}
+	for _, e := range widthEngines {
+		cols = append(cols, e.name)
+	}
+	f, err := NewFormat(format, as, cols...)
+	if err != nil {
+		return err
+	}
+
+	var texts []string
+	for _, l := range strings.Split(strings.Join(args, "\n"), "\n") {
+		if !byGrapheme {
+			texts = append(texts, l)
+			continue
+		}
+		g := uniseg.NewGraphemes(l)
+		for g.Next() {
+			texts = append(texts, g.Str())
+		}
+	}
+
+	found := false
+	for _, t := range texts {
+		line := make(map[string]string, len(widthEngines)+2)
+		same := true
+		for i, e := range widthEngines {
+			w := strconv.Itoa(e.width(t))
+			line[e.name] = w
+			if i > 0 && w != line[widthEngines[0].name] {
+				same = false
+			}
+		}
+		if diff && same {
+			continue
+		}
+
+		cp := make([]string, 0, len(t))
+		for _, r := range t {
+			cp = append(cp, fmt.Sprintf("U+%04X", r))
+		}
+		line["text"], line["cpoints"] = t, strings.Join(cp, " ")
+		f.Line(0, line)
+		found = true
+	}
+	if !found {
+		return errNoMatches
+	}
+	f.Print(zli.Stdout)
+	return nil
+}